@@ -0,0 +1,35 @@
+Feature: Calculated variables
+
+  Scenario: Calculating variables with expressions
+    Given variables are set to values
+      | $price | 12.5  |
+      | $qty   | 4     |
+      | $name  | "foo" |
+
+    # Expressions support arithmetic, comparisons, logical operators and parentheses.
+    When variable $total is calculated as $price * $qty + 1
+    Then variable $total equals to 51
+
+    When variable $next is calculated as ($qty + 1) * 2 - 10 % 3
+    Then variable $next equals to 9
+
+    When variable $expensive is calculated as $total >= 50 && !($qty == 3)
+    Then variable $expensive equals to true
+
+    When variable $greeting is calculated as "hello, " + $name
+    Then variable $greeting equals to "hello, foo"
+
+    # Factories and generators can be used in expressions.
+    When variable $deadline is calculated as addDuration(now(), "1h") > now()
+    Then variable $deadline equals to true
+
+    When variable $generated is calculated as gen:new-id + 1
+    Then variable $generated equals to 1338
+
+    # Expressions also work in tables of values.
+    When variables are set to values
+      | $subtotal | $price * $qty |
+      | $discount | -$subtotal / 10 |
+    Then variables are equal to values
+      | $subtotal | 50 |
+      | $discount | -5 |
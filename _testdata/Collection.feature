@@ -0,0 +1,19 @@
+Feature: Collection assertions
+
+  Scenario: Asserting arrays and objects
+    Given variable $id is set to 2
+    And variable $list is set to [{"id": 1}, {"id": 2}, "abc", 3]
+    And variable $obj is set to {"id": 1, "name": "John", "tags": ["a", "b"]}
+
+    Then variable $list has length 4
+    And variable $obj has length 3
+    And variable $list contains {"id": "$id"}
+    And variable $list contains all of
+      | "abc"     |
+      | 3         |
+      | {"id": 1} |
+    And variable $obj has keys
+      | id   |
+      | name |
+    And variable $list equals to [3, "abc", {"id": 1}, {"id": "$id"}] in any order
+    And variable $list does not equal to [3, "abc", {"id": 1}, {"id": 2}]
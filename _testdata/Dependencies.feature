@@ -0,0 +1,46 @@
+Feature: Dependencies between variables
+
+  Scenario: Rows are evaluated in order of dependencies
+    Given variable $user is set to "outer"
+
+    When variables are set to values
+      | $greeting | "Hello, $userName!"                     |
+      | $profile  | newUser(name="$userName", age=$userAge) |
+      | $userName | upper("$first")                         |
+      | $userAge  | 20 + $offset                            |
+      | $first    | "jane"                                  |
+      | $offset   | 1                                       |
+
+    Then variables are equal to values
+      | $greeting | "Hello, JANE!"                          |
+      | $profile  | {"name":"JANE","age":21,"role":"user"}  |
+      | $userName | "JANE"                                  |
+      | $userAge  | 21                                      |
+      | $user     | "outer"                                 |
+
+  Scenario: Variable can be redefined in the same table
+    Given variable $count is set to 1
+
+    When variables are set to values
+      | $next  | $count + 10 |
+      | $count | $count + 1  |
+      | $count | $count * 2  |
+
+    Then variables are equal to values
+      | $count | 4  |
+      | $next  | 11 |
+
+  Scenario: Row uses value of a variable defined above
+    When variables are set to values
+      | $a | 1      |
+      | $b | $a + 1 |
+      | $a | $b * 5 |
+      | $c | $d + 1 |
+      | $d | 1      |
+      | $d | 2      |
+
+    Then variables are equal to values
+      | $a | 10 |
+      | $b | 2  |
+      | $c | 3  |
+      | $d | 2  |
@@ -0,0 +1,25 @@
+Feature: Built-in generators
+
+  Scenario: Generating values with parameters
+    When variables are set to values
+      | $uuid  | gen:uuid             |
+      | $ulid  | gen:ulid             |
+      | $code  | gen:alphanum(7)      |
+      | $token | gen:hex(32)          |
+      | $num   | gen:int(10, 12)      |
+      | $email | gen:email            |
+      | $port  | gen:free-port        |
+      | $user  | gen:prefixed("user") |
+
+    Then variable $uuid matches regexp "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"
+    And variable $ulid matches regexp "^[0-9A-HJKMNP-TV-Z]{26}$"
+    And variable $code matches regexp "^[a-zA-Z0-9]{7}$"
+    And variable $token matches regexp "^[0-9a-f]{32}$"
+    And variable $num matches regexp "^1[0-2]$"
+    And variable $email matches regexp "^user-[a-z0-9]{10}@example.com$"
+    And variable $port matches regexp "^\d+$"
+    And variable $user matches regexp "^user-12345$"
+
+  Scenario: Generating values with scenario context
+    When variable $name is set to gen:scenarioScopedName("user")
+    Then variable $name equals to "user-generating-values-with-scenario-context"
@@ -0,0 +1,19 @@
+Feature: JSON path extraction
+
+  Scenario: Setting variables from JSON paths
+    Given variable $resp is set to
+    """json
+    {"user": {"id": 123, "name": "John", "roles": ["admin", "qa"]}}
+    """
+
+    When variable $userId is set from $resp at JSON path $.user.id
+    Then variable $userId equals to 123
+
+    When variables are set from $resp at JSON paths
+      | $userName  | $.user.name     |
+      | $firstRole | $.user.roles[0] |
+      | $user      | $.user          |
+    Then variables are equal to values
+      | $userName  | "John"                                            |
+      | $firstRole | "admin"                                           |
+      | $user      | {"id": 123, "name": "John", "roles": ["admin", "qa"]} |
@@ -0,0 +1,24 @@
+Feature: JSON schema
+
+  Scenario: Validating variables with JSON schema
+    Given variable $name is set to "John"
+    And variable $user is set to
+    """json
+    {"id": 123, "name": "John", "tags": ["admin", "qa"]}
+    """
+
+    # Schema can be defined as JSON5 and can use variables.
+    Then variable $user matches JSON schema
+    """json5
+    {
+      // Vars are replaced before validation.
+      type: "object",
+      required: ["id", "name"],
+      properties: {
+        id: {type: "integer"},
+        name: {const: "$name"},
+      }
+    }
+    """
+
+    And variable $user matches JSON schema from file "_testdata/schemas/user.json"
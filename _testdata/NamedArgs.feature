@@ -0,0 +1,18 @@
+Feature: Named arguments
+
+  Scenario: Calling factories with named arguments
+    Given variable $name is set to "John"
+
+    When variables are set to values
+      | $named    | newUser(name="$name", age=30, role="admin") |
+      | $mixed    | newUser("Jane", age=25)                     |
+      | $reversed | newUser(age=40, name="Jack")                |
+      | $calc     | newUser(age=20 + 1, name=upper("jill"))     |
+
+    Then variable $named equals to {"name":"John","age":30,"role":"admin"}
+    And variable $mixed equals to {"name":"Jane","age":25,"role":"user"}
+    And variable $reversed equals to {"name":"Jack","age":40,"role":"user"}
+    And variable $calc equals to {"name":"JILL","age":21,"role":"user"}
+
+    When variable $user is calculated as newUser(role="guest", name="Joe", age=3)
+    Then variable $user equals to {"name":"Joe","age":3,"role":"guest"}
@@ -0,0 +1,27 @@
+Feature: Negative assertions
+
+  Scenario: Asserting variables do not match values
+    Given variables are set to values
+      | $oldToken | "abc" |
+      | $newToken | "def" |
+    And variable $user is set to
+    """json
+    {"id": 123, "name": "John", "tags": ["a", "b"]}
+    """
+
+    Then variable $newToken does not equal to "$oldToken"
+    And variable $newToken does not equal to 123
+    And variable $newToken equals to "def"
+
+    And variable $user does not match JSON paths
+      | $.id   | 321        |
+      | $.name | "$oldToken" |
+      | $.tags | ["a"]      |
+
+    And variable $user does not have JSON path $.password
+    And variable $user does not have JSON paths
+      | $.token      |
+      | $.tags[2]    |
+      | $..password  |
+      | $.tags[*].id |
+      | $.name.first |
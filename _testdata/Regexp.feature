@@ -0,0 +1,15 @@
+Feature: Regular expressions
+
+  Scenario: Matching variables with regular expressions
+    Given variable $order is set to "order-12345/processed"
+    And variable $count is set to 42
+
+    # Named capture groups populate variables.
+    Then variable $order matches regexp "^order-(?P<orderId>\d+)/(?P<status>\w+)$"
+    And variable $orderId equals to "12345"
+    And variable $status equals to "processed"
+
+    # Non-string values are matched in JSON form.
+    And variable $count matches regexp "^\d+$"
+    And variable $order does not match regexp "^\d+$"
+    And variable $count does not match regexp "^order-"
@@ -0,0 +1,18 @@
+Feature: Reset of feature variables
+
+  Scenario: Setting feature variables
+    Given variables are set to values once in this feature
+      | $resetCounter | gen:resetSeq |
+    Then variable $resetCounter equals to 1
+
+  Scenario: Resetting feature variables
+    Given variable $resetCounter equals to 1
+    When feature variables are reset
+    Then variable $resetCounter is undefined
+
+    When variables are set to values once in this feature
+      | $resetCounter | gen:resetSeq |
+    Then variable $resetCounter equals to 2
+
+  Scenario: Using feature variables after reset
+    Given variable $resetCounter equals to 2
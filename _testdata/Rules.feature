@@ -0,0 +1,41 @@
+Feature: Rule scope
+
+  Background:
+    Given variables are set to values once in this feature
+      | $featureCounter | gen:ruleSeq |
+
+  Rule: First rule
+
+    Scenario: First scenario of first rule
+      Given variables are set to values once in this rule
+        | $ruleCounter | gen:ruleSeq |
+      Then variables are equal to values
+        | $featureCounter | 1 |
+        | $ruleCounter    | 2 |
+
+    Scenario: Second scenario of first rule
+      Given variable $ruleCounter equals to 2
+      And variables are set to values once in this rule
+        | $ruleCounter | gen:ruleSeq |
+      Then variable $ruleCounter equals to 2
+
+  Rule: Second rule
+
+    Scenario: First scenario of second rule
+      Given variable $ruleCounter is undefined
+      When variables are set to values once in this rule
+        | $ruleCounter | gen:ruleSeq |
+      Then variables are equal to values
+        | $featureCounter | 1 |
+        | $ruleCounter    | 3 |
+
+    Scenario Outline: Outline of second rule
+      When variable $ruleCounter is unset in this rule
+      And variables are set to values once in this rule
+        | $ruleCounter | <value> |
+      Then variable $ruleCounter equals to <value>
+
+      Examples:
+        | value |
+        | 4     |
+        | 5     |
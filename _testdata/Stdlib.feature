@@ -0,0 +1,86 @@
+Feature: Built-in factories
+
+  Scenario: Using built-in factories
+    Given variable $name is set to "John Doe"
+    And variable $list is set to [1, 2, 3]
+
+    When variables are set to values
+      | $yesterday | formatTime(addDuration(now(), "-24h"), "2006-01-02") |
+      | $greeting  | concat("Hello ", "$name", "! #", 1)                  |
+      | $upper     | upper("$name")                                       |
+      | $lower     | lower("$name")                                       |
+      | $encoded   | base64("$name")                                      |
+      | $decoded   | unbase64("Sm9obiBEb2U=")                             |
+      | $hash      | sha256("abc")                                        |
+      | $query     | urlencode("a b&c=d")                                 |
+      | $json      | jsonEncode("$list")                                  |
+      | $decodedJS | jsonDecode("{\"a\":[true]}")                        |
+      | $nameLen   | len("$name")                                         |
+      | $listLen   | len("$list")                                         |
+
+    Then variables are equal to values
+      | $yesterday | "2023-05-21"                                                         |
+      | $greeting  | "Hello John Doe! #1"                                                 |
+      | $upper     | "JOHN DOE"                                                           |
+      | $lower     | "john doe"                                                           |
+      | $encoded   | "Sm9obiBEb2U="                                                       |
+      | $decoded   | "John Doe"                                                           |
+      | $hash      | "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" |
+      | $query     | "a+b%26c%3Dd"                                                        |
+      | $json      | "[1,2,3]"                                                            |
+      | $decodedJS | {"a":[true]}                                                         |
+      | $nameLen   | 8                                                                    |
+      | $listLen   | 3                                                                    |
+
+  Scenario: Passing strings, literals and nested calls as arguments
+    Given variable $name is set to "John Doe"
+
+    When variables are set to values
+      | $commas   | concat("a,b", ", ", "(c)")                   |
+      | $parens   | concat(upper("x)"), lower("(Y"), ")")        |
+      | $nested   | concat(upper("a"), lower("B"), len("$name")) |
+      | $literals | jsonEncode({"name": "$name", "ids": [1, 2]}) |
+      | $array    | len([1, "a,b", [2, 3]])                      |
+
+    Then variables are equal to values
+      | $commas   | "a,b, (c)"                            |
+      | $parens   | "X)(y)"                               |
+      | $nested   | "Ab8"                                 |
+      | $literals | "{\"ids\":[1,2],\"name\":\"John Doe\"}" |
+      | $array    | 3                                     |
+
+  Scenario: Chaining transformations with pipes
+    Given variable $resp is set to {"items":[{"id":12,"name":"john"},{"id":13,"name":"jane"}]}
+    And variable $raw is set to "{\"user\":{\"name\":\"bob\"}}"
+
+    When variables are set to values
+      | $firstID   | $resp \| jsonpath("$.items[0].id") \| string                    |
+      | $firstName | $resp \| jsonpath("$.items[0].name") \| upper                   |
+      | $rawName   | $raw \| jsonpath("$.user.name") \| upper()                      |
+      | $date      | now() \| addDuration("-1h") \| formatTime("2006-01-02 15:04") |
+      | $sum       | 1 + 2 \| string \| concat("!")                                  |
+
+    Then variables are equal to values
+      | $firstID   | "12"               |
+      | $firstName | "JOHN"             |
+      | $rawName   | "BOB"              |
+      | $date      | "2023-05-22 18:38" |
+      | $sum       | "3!"               |
+
+    When variable $name is set to $resp | jsonpath("$.items[1].name") | upper
+    Then variable $name equals to "JANE"
+
+  Scenario: Evaluating templates in values
+    Given variable $name is set to "John Doe"
+
+    When variable $body is set to
+    """json5
+    // Templates are evaluated with factories and generators.
+    {
+      "id": "${gen:new-id}",
+      "user": "${upper(\"$name\")}",
+      "path": "/users/${lower(\"$name\") | urlencode}"
+    }
+    """
+
+    Then variable $body equals to {"id":1337,"user":"JOHN DOE","path":"/users/john+doe"}
@@ -0,0 +1,23 @@
+Feature: Unsetting variables
+
+  Scenario: Unsetting shared variables
+    Given variables are set to values once in this feature
+      | $fv | 1 |
+    And variables are set to values once globally
+      | $gv | 2 |
+    And variable $foo is set to null
+    And variable $bar is set to "bar"
+
+    When variable $foo is unset
+    Then variable $foo is undefined
+    And variable $bar equals to "bar"
+
+    When variable $fv is unset in this feature
+    And variable $gv is unset globally
+    Then variable $fv is undefined
+    And variable $gv is undefined
+
+  Scenario: Shared variables are not injected after unset
+    Then variable $fv is undefined
+    And variable $gv is undefined
+    And variable $foo is undefined
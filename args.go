@@ -0,0 +1,131 @@
+package vars
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// NamedArgs contains named arguments of a factory or generator call, e.g. newUser(name="John", age=30).
+//
+// Named arguments are passed to the function as the last argument after positional arguments.
+type NamedArgs map[string]interface{}
+
+// Args provides access to positional and named arguments of a factory or generator call.
+type Args struct {
+	Positional []interface{}
+	Named      NamedArgs
+}
+
+// NewArgs splits arguments of a factory or generator call into positional and named.
+func NewArgs(args ...interface{}) Args {
+	a := Args{Positional: args}
+
+	if len(args) > 0 {
+		if named, ok := args[len(args)-1].(NamedArgs); ok {
+			a.Positional = args[:len(args)-1]
+			a.Named = named
+		}
+	}
+
+	return a
+}
+
+// Get returns argument by name, or by position if named argument is missing.
+//
+// Use negative position for arguments that can only be named, or empty name for positional-only arguments.
+func (a Args) Get(pos int, name string) (interface{}, bool) {
+	if name != "" {
+		if v, ok := a.Named[name]; ok {
+			return v, true
+		}
+	}
+
+	if pos >= 0 && pos < len(a.Positional) {
+		return a.Positional[pos], true
+	}
+
+	return nil, false
+}
+
+func (a Args) get(pos int, name string) (interface{}, error) {
+	v, ok := a.Get(pos, name)
+	if !ok {
+		return nil, fmt.Errorf("missing argument %s", argName(pos, name))
+	}
+
+	return v, nil
+}
+
+func argName(pos int, name string) string {
+	switch {
+	case name == "":
+		return strconv.Itoa(pos + 1)
+	case pos < 0:
+		return name
+	default:
+		return fmt.Sprintf("%d (%s)", pos+1, name)
+	}
+}
+
+// String returns string argument.
+func (a Args) String(pos int, name string) (string, error) {
+	v, err := a.get(pos, name)
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %s: unexpected type %T, string expected", argName(pos, name), v)
+	}
+
+	return s, nil
+}
+
+// Int returns integer argument.
+func (a Args) Int(pos int, name string) (int, error) {
+	v, err := a.get(pos, name)
+	if err != nil {
+		return 0, err
+	}
+
+	f, ok := toFloat(v)
+	i, fits := floatToInt(f, strconv.IntSize)
+
+	if !ok || !fits {
+		return 0, fmt.Errorf("argument %s: unexpected value %s, integer expected", argName(pos, name), canonicalJSON(v))
+	}
+
+	return int(i), nil
+}
+
+// Bool returns boolean argument.
+func (a Args) Bool(pos int, name string) (bool, error) {
+	v, err := a.get(pos, name)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("argument %s: unexpected type %T, bool expected", argName(pos, name), v)
+	}
+
+	return b, nil
+}
+
+// Time returns time argument, RFC3339 strings are converted to time.Time.
+func (a Args) Time(pos int, name string) (time.Time, error) {
+	v, err := a.get(pos, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := asTime(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("argument %s: %w", argName(pos, name), err)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,23 @@
+package vars_test
+
+import (
+	"testing"
+
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgs_Int(t *testing.T) {
+	a := vars.NewArgs(float64(42), 1e20, 1.5)
+
+	i, err := a.Int(0, "n")
+	require.NoError(t, err)
+	assert.Equal(t, 42, i)
+
+	_, err = a.Int(1, "big")
+	assert.EqualError(t, err, "argument 2 (big): unexpected value 100000000000000000000, integer expected")
+
+	_, err = a.Int(2, "half")
+	assert.EqualError(t, err, "argument 3 (half): unexpected value 1.5, integer expected")
+}
@@ -0,0 +1,200 @@
+package vars
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// globalCacheEntry is a value of a variable set once globally, stored in GlobalCacheFile.
+type globalCacheEntry struct {
+	Value       interface{} `json:"value"`
+	Fingerprint string      `json:"fingerprint"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
+
+// fingerprint identifies expression of a variable together with expressions of its dependencies,
+// cached value is not reused if any of them changes.
+func fingerprint(name, value string, deps []string) string {
+	h := sha256.New()
+
+	for _, part := range append([]string{name, value}, deps...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadGlobalCache reads GlobalCacheFile once, missing file is an empty cache.
+//
+// With RefreshGlobalCache, cached values are ignored once until ResetGlobals, values stored after that are kept.
+// Must be called with s.mu locked.
+func (s *Steps) loadGlobalCache() error {
+	if s.RefreshGlobalCache && !s.cacheRefreshed {
+		s.globalCache = make(map[string]globalCacheEntry)
+		s.cacheRefreshed = true
+
+		return nil
+	}
+
+	if s.globalCache != nil {
+		return nil
+	}
+
+	s.globalCache = make(map[string]globalCacheEntry)
+
+	data, err := os.ReadFile(s.GlobalCacheFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return fmt.Errorf("reading global cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.globalCache); err != nil {
+		return fmt.Errorf("decoding global cache %s: %w", s.GlobalCacheFile, err)
+	}
+
+	return nil
+}
+
+// useGlobalCache sets global variables of the table from GlobalCacheFile if cached values are still valid.
+//
+// Value is not reused if any of its dependencies in the table is evaluated again.
+// It returns fingerprints of table rows that are not set yet and should be stored after evaluation.
+// Must be called with s.mu locked.
+func (s *Steps) useGlobalCache(table *godog.Table) (map[int]string, error) {
+	if err := s.loadGlobalCache(); err != nil {
+		return nil, err
+	}
+
+	// Expired values are looked up in cache again.
+	s.expire(globalScope, s.globalVars)
+
+	g, err := newTableGraph(table, nil, s.globalVars)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := g.order()
+	if err != nil {
+		return nil, err
+	}
+
+	fps := make([]string, len(g.rows))
+	missing := make(map[int]string)
+
+	for _, i := range order {
+		r := g.rows[i]
+		deps := make([]string, 0, len(r.deps))
+		depsMissing := false
+
+		for _, j := range r.deps {
+			deps = append(deps, fps[j])
+
+			if _, found := missing[j]; found {
+				depsMissing = true
+			}
+		}
+
+		fps[i] = fingerprint(r.name, r.value, deps)
+
+		if _, found := s.globalVars[r.name]; found {
+			continue
+		}
+
+		if e, found := s.globalCache[r.name]; found && !depsMissing && s.isValid(e, fps[i]) {
+			s.globalVars[r.name] = e.Value
+			s.setExpiry(globalScope, r.name, e.CreatedAt)
+
+			continue
+		}
+
+		missing[i] = fps[i]
+	}
+
+	return missing, nil
+}
+
+// isValid checks if cached value can be reused for the variable fingerprint.
+//
+// Value is not reused if it is older than GlobalCacheTTL or OnceTTL.
+func (s *Steps) isValid(e globalCacheEntry, fp string) bool {
+	if e.Fingerprint != fp {
+		return false
+	}
+
+	age := time.Since(e.CreatedAt)
+
+	return (s.GlobalCacheTTL <= 0 || age <= s.GlobalCacheTTL) && (s.OnceTTL <= 0 || age <= s.OnceTTL)
+}
+
+// storeGlobalCache writes evaluated global variables of the table to GlobalCacheFile.
+// Must be called with s.mu locked.
+func (s *Steps) storeGlobalCache(table *godog.Table, rows map[int]string) error {
+	changed := false
+
+	for i, fp := range rows {
+		name := table.Rows[i].Cells[0].Value
+
+		// Resource of the value is deleted after suite, so the value can not be reused by another run.
+		if s.globalCleanups.has(name) {
+			if _, found := s.globalCache[name]; found {
+				delete(s.globalCache, name)
+
+				changed = true
+			}
+
+			continue
+		}
+
+		val, found := s.globalVars[name]
+		if !found {
+			continue
+		}
+
+		s.globalCache[name] = globalCacheEntry{Value: val, Fingerprint: fp, CreatedAt: time.Now()}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.globalCache, "", " ")
+	if err != nil {
+		return fmt.Errorf("encoding global cache: %w", err)
+	}
+
+	// File is replaced atomically, so that interrupted run does not leave a broken cache.
+	tmp, err := os.CreateTemp(filepath.Dir(s.GlobalCacheFile), filepath.Base(s.GlobalCacheFile)+".*")
+	if err != nil {
+		return fmt.Errorf("writing global cache: %w", err)
+	}
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.GlobalCacheFile)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("writing global cache: %w", err)
+	}
+
+	return nil
+}
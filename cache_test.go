@@ -0,0 +1,179 @@
+package vars_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSteps_GlobalCacheFile(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "vars-cache.json")
+	created := 0
+
+	// Every run uses new Steps, same as a new test process.
+	run := func(expr string, configure func(vs *vars.Steps)) int {
+		vs := &vars.Steps{GlobalCacheFile: cacheFile}
+		vs.AddGenerator("newKey", func() (interface{}, error) {
+			created++
+
+			return created, nil
+		})
+
+		if configure != nil {
+			configure(vs)
+		}
+
+		suite := godog.TestSuite{
+			ScenarioInitializer: vs.Register,
+			Options: &godog.Options{
+				Format: "progress",
+				Strict: true,
+				FeatureContents: []godog.Feature{
+					{
+						Name: "Cache.feature",
+						Contents: []byte(`Feature: Cache
+  Scenario: Expensive fixture
+    Given variables are set to values once globally
+      | $key     | ` + expr + ` |
+      | $derived | "key-$key"  |
+`),
+					},
+				},
+			},
+		}
+
+		require.Zero(t, suite.Run())
+
+		return created
+	}
+
+	assert.Equal(t, 1, run("gen:newKey", nil))
+	assert.Equal(t, 1, run("gen:newKey", nil), "value is reused")
+
+	data, err := os.ReadFile(cacheFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"$derived": {`)
+	assert.Contains(t, string(data), `"value": "key-1"`)
+
+	assert.Equal(t, 2, run("gen:newKey + 0", nil), "expression is changed")
+
+	data, err = os.ReadFile(cacheFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"value": "key-2"`, "dependent value is changed")
+
+	assert.Equal(t, 2, run("gen:newKey + 0", nil))
+
+	assert.Equal(t, 3, run("gen:newKey + 0", func(vs *vars.Steps) {
+		vs.RefreshGlobalCache = true
+	}))
+
+	assert.Equal(t, 3, run("gen:newKey + 0", func(vs *vars.Steps) {
+		vs.GlobalCacheTTL = time.Hour
+	}))
+
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, 4, run("gen:newKey + 0", func(vs *vars.Steps) {
+		vs.GlobalCacheTTL = time.Millisecond
+	}), "value is expired")
+}
+
+func TestSteps_GlobalCacheFile_lifetime(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "vars-cache.json")
+	created, cleaned := 0, 0
+
+	var keys []interface{}
+
+	newSteps := func() *vars.Steps {
+		vs := &vars.Steps{GlobalCacheFile: cacheFile}
+		vs.AddGenerator("newKey", func() (interface{}, error) {
+			created++
+
+			return created, nil
+		})
+		vs.AddCleanupFactory("newResource", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+			return ctx, "resource", func(ctx context.Context) error {
+				cleaned++
+
+				return nil
+			}, nil
+		})
+
+		return vs
+	}
+
+	run := func(vs *vars.Steps, scenarios int) {
+		t.Helper()
+
+		scenario := `
+  Scenario: Expensive fixture
+    Given time passes
+    And variables are set to values once globally
+      | $key      | gen:newKey    |
+      | $resource | newResource() |
+    Then key is collected
+`
+
+		suite := godog.TestSuite{
+			TestSuiteInitializer: vs.RegisterSuite,
+			ScenarioInitializer: func(sc *godog.ScenarioContext) {
+				vs.Register(sc)
+
+				sc.Step(`^key is collected$`, func(ctx context.Context) {
+					keys = append(keys, vars.FromContext(ctx)["$key"])
+				})
+				sc.Step(`^time passes$`, func() { time.Sleep(150 * time.Millisecond) })
+				sc.Step(`^nothing happens$`, func() {})
+			},
+			Options: &godog.Options{
+				Format: "progress",
+				Strict: true,
+				FeatureContents: []godog.Feature{{
+					Name:     "Cache.feature",
+					Contents: []byte("Feature: Cache\n" + strings.Replace(strings.Repeat(scenario, scenarios), "time passes", "nothing happens", 1)),
+				}},
+			},
+		}
+
+		require.Zero(t, suite.Run())
+		require.NoError(t, vs.SuiteError())
+	}
+
+	vs := newSteps()
+	run(vs, 1)
+
+	data, err := os.ReadFile(cacheFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `$resource`, "value with cleanup is not stored")
+
+	// Cached value expires with OnceTTL same as evaluated value.
+	other := newSteps()
+	other.OnceTTL = 100 * time.Millisecond
+	run(other, 2)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, []interface{}{1, float64(1), 2}, keys[:3])
+	assert.Equal(t, 3, cleaned, "resource is created again in every run and after expiration")
+
+	// Values stored by other Steps are read again after reset.
+	vs.ResetGlobals()
+	run(vs, 1)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, float64(2), keys[3])
+
+	// Refresh applies after every reset.
+	vs.RefreshGlobalCache = true
+	vs.ResetGlobals()
+	run(vs, 1)
+	vs.ResetGlobals()
+	run(vs, 1)
+	assert.Equal(t, 4, created)
+	assert.Equal(t, []interface{}{3, 4}, keys[4:])
+}
@@ -0,0 +1,153 @@
+package vars
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Doc describes a factory or generator for catalog.
+type Doc struct {
+	// Name is set on registration.
+	Name string `json:"name"`
+	// Signature shows how to call the function, e.g. addDuration(time, duration).
+	Signature   string   `json:"signature,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Args        []ArgDoc `json:"args,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+}
+
+// ArgDoc describes an argument of a factory or generator.
+type ArgDoc struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Catalog lists registered factories and generators, it can be encoded as JSON or rendered as Markdown.
+type Catalog struct {
+	Factories  []Doc `json:"factories"`
+	Generators []Doc `json:"generators"`
+}
+
+// Factories returns documentation of registered factories sorted by name.
+//
+// Factories registered without Doc are listed by name.
+func (s *Steps) Factories() []Doc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.factories))
+	for name := range s.factories {
+		names = append(names, name)
+	}
+
+	return docsByName(names, s.factoryDocs)
+}
+
+// Generators returns documentation of registered generators sorted by name.
+//
+// Generators registered without Doc are listed by name.
+func (s *Steps) Generators() []Doc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.generators))
+	for name := range s.generators {
+		names = append(names, name)
+	}
+
+	return docsByName(names, s.generatorDocs)
+}
+
+// Catalog returns documentation of registered factories and generators.
+func (s *Steps) Catalog() Catalog {
+	return Catalog{
+		Factories:  s.Factories(),
+		Generators: s.Generators(),
+	}
+}
+
+func docsByName(names []string, docs map[string]Doc) []Doc {
+	sort.Strings(names)
+
+	res := make([]Doc, 0, len(names))
+
+	for _, name := range names {
+		d := docs[name]
+		d.Name = name
+
+		res = append(res, d)
+	}
+
+	return res
+}
+
+// setDoc stores documentation of a function, last doc wins.
+//
+// Function registered again without doc loses previous doc, as it may not describe the new function.
+// Must be called with s.mu locked.
+func setDoc(docs *map[string]Doc, name string, d []Doc) {
+	if len(d) == 0 {
+		delete(*docs, name)
+
+		return
+	}
+
+	if *docs == nil {
+		*docs = make(map[string]Doc)
+	}
+
+	doc := d[len(d)-1]
+	doc.Name = name
+
+	(*docs)[name] = doc
+}
+
+// Markdown renders catalog as Markdown document.
+func (c Catalog) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("## Factories\n")
+	writeDocs(&sb, c.Factories, "")
+
+	sb.WriteString("\n## Generators\n")
+	writeDocs(&sb, c.Generators, "gen:")
+
+	return sb.String()
+}
+
+func writeDocs(sb *strings.Builder, docs []Doc, prefix string) {
+	if len(docs) == 0 {
+		sb.WriteString("\nNone registered.\n")
+
+		return
+	}
+
+	for _, d := range docs {
+		_, _ = fmt.Fprintf(sb, "\n### %s%s\n", prefix, d.Name)
+
+		if d.Signature != "" {
+			_, _ = fmt.Fprintf(sb, "\n`%s%s`\n", prefix, d.Signature)
+		}
+
+		if d.Description != "" {
+			_, _ = fmt.Fprintf(sb, "\n%s\n", d.Description)
+		}
+
+		if len(d.Args) > 0 {
+			sb.WriteString("\n| Argument | Description |\n|----------|-------------|\n")
+
+			for _, a := range d.Args {
+				_, _ = fmt.Fprintf(sb, "| `%s` | %s |\n", a.Name, strings.ReplaceAll(a.Description, "|", `\|`))
+			}
+		}
+
+		if len(d.Examples) > 0 {
+			sb.WriteString("\nExamples:\n\n")
+
+			for _, e := range d.Examples {
+				_, _ = fmt.Fprintf(sb, "* `%s`\n", e)
+			}
+		}
+	}
+}
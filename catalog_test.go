@@ -0,0 +1,121 @@
+package vars_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSteps_Catalog(t *testing.T) {
+	vs := &vars.Steps{}
+
+	vs.AddFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		return ctx, 1, nil
+	}, vars.Doc{
+		Signature:   "newUser(name, role)",
+		Description: "Creates user and returns its ID.",
+		Args: []vars.ArgDoc{
+			{Name: "name", Description: "user name"},
+			{Name: "role", Description: "admin | user"},
+		},
+		Examples: []string{`newUser("John", role="admin")`},
+	})
+	vs.AddFunc("addDays", func(t time.Time, days int) time.Time { return t.AddDate(0, 0, days) })
+	vs.AddGenerator("seq", func() (interface{}, error) { return 1, nil })
+
+	c := vs.Catalog()
+
+	assert.Equal(t, []vars.Doc{
+		{Name: "addDays", Signature: "addDays(time.Time, int)"},
+		{
+			Name:        "newUser",
+			Signature:   "newUser(name, role)",
+			Description: "Creates user and returns its ID.",
+			Args: []vars.ArgDoc{
+				{Name: "name", Description: "user name"},
+				{Name: "role", Description: "admin | user"},
+			},
+			Examples: []string{`newUser("John", role="admin")`},
+		},
+	}, c.Factories)
+	assert.Equal(t, []vars.Doc{{Name: "seq"}}, c.Generators)
+
+	assert.Equal(t, "## Factories\n"+
+		"\n### addDays\n"+
+		"\n`addDays(time.Time, int)`\n"+
+		"\n### newUser\n"+
+		"\n`newUser(name, role)`\n"+
+		"\nCreates user and returns its ID.\n"+
+		"\n| Argument | Description |\n"+
+		"|----------|-------------|\n"+
+		"| `name` | user name |\n"+
+		"| `role` | admin \\| user |\n"+
+		"\nExamples:\n\n"+
+		"* `newUser(\"John\", role=\"admin\")`\n"+
+		"\n## Generators\n"+
+		"\n### gen:seq\n", c.Markdown())
+
+	j, err := json.Marshal(c)
+	require.NoError(t, err)
+	assert.Equal(t, `{"factories":[{"name":"addDays","signature":"addDays(time.Time, int)"},`+
+		`{"name":"newUser","signature":"newUser(name, role)","description":"Creates user and returns its ID.",`+
+		`"args":[{"name":"name","description":"user name"},{"name":"role","description":"admin | user"}],`+
+		`"examples":["newUser(\"John\", role=\"admin\")"]}],"generators":[{"name":"seq"}]}`, string(j))
+}
+
+func TestSteps_Catalog_std(t *testing.T) {
+	vs := &vars.Steps{}
+
+	vs.AddFactory("upper", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		return ctx, nil, nil
+	})
+	vs.AddStdFactories()
+	vs.AddStdGenerators()
+
+	for _, d := range vs.Factories() {
+		if d.Name == "upper" {
+			// Custom factory takes precedence with its documentation.
+			assert.Equal(t, vars.Doc{Name: "upper"}, d)
+
+			continue
+		}
+
+		assert.NotEmpty(t, d.Signature, d.Name)
+		assert.NotEmpty(t, d.Description, d.Name)
+	}
+
+	for _, d := range vs.Generators() {
+		assert.NotEmpty(t, d.Description, d.Name)
+	}
+
+	assert.Contains(t, vs.Catalog().Markdown(), "\n### gen:alphanum\n\n`gen:alphanum(length)`\n")
+}
+
+func TestSteps_Catalog_override(t *testing.T) {
+	vs := &vars.Steps{}
+	vs.AddStdFactories()
+	vs.AddStdGenerators()
+
+	vs.AddFactory("upper", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		return ctx, nil, nil
+	})
+	vs.AddGenerator("uuid", func() (interface{}, error) { return "u", nil })
+
+	for _, d := range vs.Factories() {
+		if d.Name == "upper" {
+			// Overriding factory without doc removes documentation of std factory.
+			assert.Equal(t, vars.Doc{Name: "upper"}, d)
+		}
+	}
+
+	for _, d := range vs.Generators() {
+		if d.Name == "uuid" {
+			assert.Equal(t, vars.Doc{Name: "uuid"}, d)
+		}
+	}
+}
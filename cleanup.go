@@ -0,0 +1,217 @@
+package vars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cucumber/godog"
+)
+
+// CleanupFactory is a function to create variable value together with a cleanup of created resource.
+//
+// Cleanup is called in reverse creation order when scope of variable ends:
+// after scenario for a scenario variable, and after suite for a variable set once
+// in a feature, rule, tag or globally. Variables set once need Steps.RegisterSuite
+// to run cleanups after suite, otherwise factory fails.
+type CleanupFactory func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error)
+
+// AddCleanupFactory registers user-defined factory function that returns cleanup of created resource.
+//
+// Optional Doc describes factory in Catalog.
+func (s *Steps) AddCleanupFactory(name string, f CleanupFactory, doc ...Doc) {
+	s.AddFactory(name, func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		c, ok := ctx.Value(cleanupsCtxKey{}).(*cleanups)
+		if !ok {
+			return ctx, nil, errors.New("missing cleanup scope in context, " + name + " can only be used in steps of Steps.Register")
+		}
+
+		if scope, ok := ctx.Value(unmanagedScopeCtxKey{}).(string); ok {
+			return ctx, nil, fmt.Errorf("resource of %s would not be cleaned up in %s scope, "+
+				"use Steps.RegisterSuite to run cleanups after suite", name, scope)
+		}
+
+		ctx, val, cleanup, err := f(ctx, args...)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		if cleanup != nil {
+			varName, _ := ctx.Value(varNameCtxKey{}).(string)
+			c.add(varName, cleanup)
+		}
+
+		return ctx, val, nil
+	}, doc...)
+}
+
+type (
+	cleanupsCtxKey        struct{}
+	featureCleanupsCtxKey struct{}
+	varNameCtxKey         struct{}
+	unmanagedScopeCtxKey  struct{}
+)
+
+// cleanupFailed prefixes errors of cleanups.
+const cleanupFailed = "cleanup failed: "
+
+// cleanups is a stack of cleanup functions of a scope.
+type cleanups struct {
+	mu   sync.Mutex
+	fns  []func(ctx context.Context) error
+	vars map[string]bool
+}
+
+// add registers cleanup of a resource created for a variable, name is empty if variable is unknown.
+func (c *cleanups) add(name string, fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fns = append(c.fns, fn)
+
+	if name != "" {
+		if c.vars == nil {
+			c.vars = make(map[string]bool)
+		}
+
+		c.vars[name] = true
+	}
+}
+
+// has checks if a cleanup was registered for a resource of a variable.
+func (c *cleanups) has(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.vars[name]
+}
+
+// moveTo transfers cleanups to another scope.
+func (c *cleanups) moveTo(dst *cleanups) {
+	c.mu.Lock()
+	fns, vars := c.fns, c.vars
+	c.fns = nil
+	c.vars = nil
+	c.mu.Unlock()
+
+	for _, fn := range fns {
+		dst.add("", fn)
+	}
+
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+
+	for name := range vars {
+		if dst.vars == nil {
+			dst.vars = make(map[string]bool)
+		}
+
+		dst.vars[name] = true
+	}
+}
+
+// run calls cleanups in reverse order and returns their errors.
+func (c *cleanups) run(ctx context.Context) []error {
+	c.mu.Lock()
+	fns := c.fns
+	c.fns = nil
+	c.vars = nil
+	c.mu.Unlock()
+
+	var errs []error
+
+	for i := len(fns) - 1; i >= 0; i-- {
+		if err := fns[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// multiError combines multiple errors with a common prefix of message.
+type multiError struct {
+	prefix string
+	errs   []error
+}
+
+func (e multiError) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return e.prefix + strings.Join(msgs, "; ")
+}
+
+// Unwrap exposes combined errors to errors.Is and errors.As of Go 1.20 and later.
+func (e multiError) Unwrap() []error {
+	return e.errs
+}
+
+// joinErrors combines errors with a prefix of message, single error stays available for errors.Is and errors.As.
+func joinErrors(prefix string, errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s%w", prefix, errs[0])
+	default:
+		return multiError{prefix: prefix, errs: errs}
+	}
+}
+
+// startScenarioCleanups prepares cleanup scopes of a scenario and of its feature.
+//
+// Scenarios of different features can run concurrently, so feature scopes end after suite, see Cleanup.
+// Must be called with s.mu locked.
+func (s *Steps) startScenarioCleanups(ctx context.Context, sc *godog.Scenario) context.Context {
+	if s.featureCleanups == nil {
+		s.featureCleanups = make(map[string]*cleanups)
+	}
+
+	if s.featureCleanups[sc.Uri] == nil {
+		s.featureCleanups[sc.Uri] = &cleanups{}
+		s.featureOrder = append(s.featureOrder, sc.Uri)
+	}
+
+	ctx = context.WithValue(ctx, featureCleanupsCtxKey{}, s.featureCleanups[sc.Uri])
+
+	return context.WithValue(ctx, cleanupsCtxKey{}, &cleanups{})
+}
+
+// runScenarioCleanups runs cleanups of scenario variables.
+func (s *Steps) runScenarioCleanups(ctx context.Context, _ *godog.Scenario, _ error) (context.Context, error) {
+	if c, ok := ctx.Value(cleanupsCtxKey{}).(*cleanups); ok {
+		return ctx, joinErrors(cleanupFailed, c.run(ctx))
+	}
+
+	return ctx, nil
+}
+
+// Cleanup runs pending cleanups of feature and global variables and returns their errors.
+//
+// It is called after suite by hooks of RegisterSuite.
+func (s *Steps) Cleanup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+
+	// Features are cleaned up in reverse order of their start, before global variables.
+	for i := len(s.featureOrder) - 1; i >= 0; i-- {
+		uri := s.featureOrder[i]
+
+		errs = append(errs, s.featureCleanups[uri].run(context.Background())...)
+
+		delete(s.featureCleanups, uri)
+	}
+
+	s.featureOrder = nil
+
+	errs = append(errs, s.globalCleanups.run(context.Background())...)
+
+	return joinErrors(cleanupFailed, errs)
+}
@@ -0,0 +1,317 @@
+package vars_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps_AddCleanupFactory(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		log []string
+	)
+
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		log = append(log, s)
+	}
+
+	vs := &vars.Steps{}
+	vs.AddCleanupFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+		name := fmt.Sprint(args...)
+		record("create " + name)
+
+		return ctx, name, func(ctx context.Context) error {
+			record("delete " + name)
+
+			return nil
+		}, nil
+	})
+
+	suite := godog.TestSuite{
+		TestSuiteInitializer: vs.RegisterSuite,
+		ScenarioInitializer: func(sc *godog.ScenarioContext) {
+			vs.Register(sc)
+
+			sc.Step(`^I log "([^"]*)"$`, record)
+		},
+		Options: &godog.Options{
+			Format: "progress",
+			Strict: true,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "First.feature",
+					Contents: []byte(`Feature: First
+  Scenario: First scenario
+    Given variables are set to values once globally
+      | $global | newUser("global") |
+    And variables are set to values once in this feature
+      | $feature | newUser("feature1") |
+    And variables are set to values
+      | $a | newUser("a") |
+      | $b | newUser("b") |
+    Then I log "first done"
+
+  Scenario: Second scenario
+    Given variables are set to values once in this feature
+      | $feature | newUser("feature1") |
+    When variable $c is set to newUser("c")
+    Then I log "second done"
+`),
+				},
+				{
+					Name: "Second.feature",
+					Contents: []byte(`Feature: Second
+  Scenario: Third scenario
+    Given variables are set to values once globally
+      | $global | newUser("global") |
+    And variables are set to values once in this feature
+      | $feature | newUser("feature2") |
+    Then I log "third done"
+`),
+				},
+			},
+		},
+	}
+
+	assert.Zero(t, suite.Run())
+	assert.NoError(t, vs.SuiteError())
+
+	assert.Equal(t, []string{
+		"create global",
+		"create feature1",
+		"create a",
+		"create b",
+		"first done",
+		"delete b",
+		"delete a",
+		"create c",
+		"second done",
+		"delete c",
+		"create feature2",
+		"third done",
+		"delete feature2",
+		"delete feature1",
+		"delete global",
+	}, log)
+}
+
+func TestSteps_AddCleanupFactory_concurrency(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created = map[string]bool{}
+		deleted []string
+	)
+
+	vs := &vars.Steps{}
+	vs.AddCleanupFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+		name := fmt.Sprint(args...)
+
+		mu.Lock()
+		created[name] = true
+		mu.Unlock()
+
+		return ctx, name, func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			deleted = append(deleted, name)
+
+			return nil
+		}, nil
+	})
+
+	feature := func(name string) godog.Feature {
+		scenario := `
+  Scenario: Using user
+    Given variables are set to values once in this feature
+      | $user | newUser("` + name + `") |
+    Then user exists
+`
+
+		return godog.Feature{
+			Name:     name + ".feature",
+			Contents: []byte("Feature: " + name + "\n" + strings.Repeat(scenario, 5)),
+		}
+	}
+
+	suite := godog.TestSuite{
+		TestSuiteInitializer: vs.RegisterSuite,
+		ScenarioInitializer: func(sc *godog.ScenarioContext) {
+			vs.Register(sc)
+
+			sc.Step(`^user exists$`, func(ctx context.Context) error {
+				name := vars.FromContext(ctx)["$user"].(string)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				for _, d := range deleted {
+					if d == name {
+						return errors.New("user " + name + " is already deleted")
+					}
+				}
+
+				return nil
+			})
+		},
+		Options: &godog.Options{
+			Format:          "progress",
+			Strict:          true,
+			Concurrency:     4,
+			FeatureContents: []godog.Feature{feature("first"), feature("second"), feature("third")},
+		},
+	}
+
+	assert.Zero(t, suite.Run())
+	assert.NoError(t, vs.SuiteError())
+	assert.ElementsMatch(t, []string{"first", "second", "third"}, deleted)
+}
+
+func TestSteps_AddCleanupFactory_errors(t *testing.T) {
+	vs := &vars.Steps{}
+	vs.AddCleanupFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+		name := fmt.Sprint(args...)
+
+		return ctx, name, func(ctx context.Context) error {
+			return errors.New("failed to delete " + name)
+		}, nil
+	})
+
+	out := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		TestSuiteInitializer: vs.RegisterSuite,
+		ScenarioInitializer:  vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Output: out,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Errors.feature",
+					Contents: []byte(`Feature: Errors
+  Scenario: Failing cleanups
+    Given variables are set to values once globally
+      | $global | newUser("global") |
+    And variables are set to values
+      | $a | newUser("a") |
+      | $b | newUser("b") |
+`),
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run())
+	assert.Contains(t, out.String(), "cleanup failed: failed to delete b; failed to delete a")
+	assert.EqualError(t, vs.SuiteError(), "cleanup failed: failed to delete global")
+}
+
+func TestSteps_AddCleanupFactory_features(t *testing.T) {
+	var deleted []string
+
+	vs := &vars.Steps{}
+	vs.AddCleanupFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+		name := fmt.Sprint(args...)
+
+		return ctx, name, func(ctx context.Context) error {
+			deleted = append(deleted, name)
+
+			return nil
+		}, nil
+	})
+
+	feature := func(name string) godog.Feature {
+		return godog.Feature{
+			Name: name + ".feature",
+			Contents: []byte(`Feature: ` + name + `
+  Scenario: Creating users
+    Given variables are set to values once globally
+      | $admin | newUser("admin") |
+    And variables are set to values once in this feature
+      | $owner  | newUser("` + name + ` owner") |
+      | $member | newUser("` + name + ` member") |
+
+  Scenario: Reusing users
+    Given variables are set to values once in this feature
+      | $owner | newUser("` + name + ` owner") |
+`),
+		}
+	}
+
+	suite := godog.TestSuite{
+		TestSuiteInitializer: vs.RegisterSuite,
+		ScenarioInitializer:  vs.Register,
+		Options: &godog.Options{
+			Format:          "progress",
+			Strict:          true,
+			FeatureContents: []godog.Feature{feature("first"), feature("second"), feature("third")},
+		},
+	}
+
+	assert.Zero(t, suite.Run())
+	assert.NoError(t, vs.SuiteError())
+
+	// Cleanups of a feature are not interleaved with cleanups of other features.
+	assert.Equal(t, []string{
+		"third member", "third owner",
+		"second member", "second owner",
+		"first member", "first owner",
+		"admin",
+	}, deleted)
+}
+
+func TestSteps_AddCleanupFactory_withoutSuite(t *testing.T) {
+	created := 0
+
+	vs := &vars.Steps{}
+	vs.AddCleanupFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+		created++
+
+		return ctx, created, func(ctx context.Context) error { return nil }, nil
+	})
+
+	for _, step := range []string{
+		"variables are set to values once globally",
+		"variables are set to values once in this feature",
+		"variables are set to values once for tag @users",
+	} {
+		out := bytes.NewBuffer(nil)
+
+		suite := godog.TestSuite{
+			ScenarioInitializer: vs.Register,
+			Options: &godog.Options{
+				Format: "progress",
+				Output: out,
+				FeatureContents: []godog.Feature{
+					{
+						Name: "Leak.feature",
+						Contents: []byte(`Feature: Leak
+  @users
+  Scenario: Shared user
+    Given ` + step + `
+      | $user | newUser() |
+`),
+					},
+				},
+			},
+		}
+
+		assert.Equal(t, 1, suite.Run(), step)
+		assert.Contains(t, out.String(), "resource of newUser would not be cleaned up in ", step)
+		assert.Contains(t, out.String(), "scope, use Steps.RegisterSuite to run cleanups after suite", step)
+	}
+
+	assert.Zero(t, created)
+}
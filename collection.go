@@ -0,0 +1,243 @@
+package vars
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cucumber/godog"
+)
+
+// expectedValue decodes JSON value with vars interpolated.
+func (s *Steps) expectedValue(ctx context.Context, value string) (interface{}, error) {
+	_, rv, err := s.Replace(ctx, []byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("replacing vars in %s: %w", value, err)
+	}
+
+	var val interface{}
+	if err := json.Unmarshal(rv, &val); err != nil {
+		return nil, fmt.Errorf("decoding %s as JSON: %w", value, err)
+	}
+
+	return val, nil
+}
+
+// canonicalJSON encodes decoded JSON value with sorted object keys.
+func canonicalJSON(v interface{}) string {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(j)
+}
+
+func (s *Steps) arrayValue(ctx context.Context, name string) ([]interface{}, error) {
+	val, err := s.jsonDecodedValue(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	arr, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("variable %s is not an array: %s", name, canonicalJSON(val))
+	}
+
+	return arr, nil
+}
+
+func (s *Steps) varHasLength(ctx context.Context, name, length string) error {
+	expected, err := strconv.Atoi(length)
+	if err != nil {
+		return fmt.Errorf("invalid length %s: %w", length, err)
+	}
+
+	val, err := s.jsonDecodedValue(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	var actual int
+
+	switch v := val.(type) {
+	case []interface{}:
+		actual = len(v)
+	case map[string]interface{}:
+		actual = len(v)
+	case string:
+		actual = utf8.RuneCountInString(v)
+	default:
+		return fmt.Errorf("variable %s has no length: %s", name, canonicalJSON(val))
+	}
+
+	if actual != expected {
+		return fmt.Errorf("variable %s has length %d, %d expected", name, actual, expected)
+	}
+
+	return nil
+}
+
+// contains checks if array has an element equal to expected, or if string has expected substring.
+func contains(collection, expected interface{}) (bool, error) {
+	switch c := collection.(type) {
+	case []interface{}:
+		e := canonicalJSON(expected)
+
+		for _, item := range c {
+			if canonicalJSON(item) == e {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return false, fmt.Errorf("string expected to check substring, %s received", canonicalJSON(expected))
+		}
+
+		return strings.Contains(c, e), nil
+	}
+
+	return false, fmt.Errorf("array or string expected, %s received", canonicalJSON(collection))
+}
+
+func (s *Steps) varContains(ctx context.Context, name, value string) error {
+	val, err := s.jsonDecodedValue(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	expected, err := s.expectedValue(ctx, value)
+	if err != nil {
+		return err
+	}
+
+	found, err := contains(val, expected)
+	if err != nil {
+		return fmt.Errorf("variable %s: %w", name, err)
+	}
+
+	if !found {
+		return fmt.Errorf("variable %s does not contain %s: %s", name, canonicalJSON(expected), canonicalJSON(val))
+	}
+
+	return nil
+}
+
+func (s *Steps) varContainsAllOf(ctx context.Context, name string, table *godog.Table) error {
+	val, err := s.jsonDecodedValue(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+
+	for _, row := range table.Rows {
+		if len(row.Cells) != 1 {
+			return fmt.Errorf("one column expected in the table, %d received", len(row.Cells))
+		}
+
+		expected, err := s.expectedValue(ctx, row.Cells[0].Value)
+		if err != nil {
+			return err
+		}
+
+		found, err := contains(val, expected)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", name, err)
+		}
+
+		if !found {
+			missing = append(missing, canonicalJSON(expected))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("variable %s does not contain %s: %s", name, strings.Join(missing, ", "), canonicalJSON(val))
+	}
+
+	return nil
+}
+
+func (s *Steps) varHasKeys(ctx context.Context, name string, table *godog.Table) error {
+	val, err := s.jsonDecodedValue(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	obj, ok := val.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("variable %s is not an object: %s", name, canonicalJSON(val))
+	}
+
+	var missing []string
+
+	for _, row := range table.Rows {
+		if len(row.Cells) != 1 {
+			return fmt.Errorf("one column expected in the table, %d received", len(row.Cells))
+		}
+
+		_, key, err := s.ReplaceString(ctx, row.Cells[0].Value)
+		if err != nil {
+			return err
+		}
+
+		if _, found := obj[key]; !found {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("variable %s does not have keys %s: %s", name, strings.Join(missing, ", "), canonicalJSON(val))
+	}
+
+	return nil
+}
+
+func (s *Steps) varEqualsInAnyOrder(ctx context.Context, name, value string) error {
+	actual, err := s.arrayValue(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	ev, err := s.expectedValue(ctx, value)
+	if err != nil {
+		return err
+	}
+
+	expected, ok := ev.([]interface{})
+	if !ok {
+		return fmt.Errorf("array expected, %s received", canonicalJSON(ev))
+	}
+
+	counts := make(map[string]int, len(actual))
+	for _, item := range actual {
+		counts[canonicalJSON(item)]++
+	}
+
+	matched := len(actual) == len(expected)
+
+	for _, item := range expected {
+		j := canonicalJSON(item)
+
+		if counts[j] == 0 {
+			matched = false
+
+			break
+		}
+
+		counts[j]--
+	}
+
+	if !matched {
+		return fmt.Errorf("variable %s assertion failed, expected %s in any order, received %s",
+			name, canonicalJSON(expected), canonicalJSON(actual))
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package vars
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// asString converts value to string, non-string values are encoded as JSON.
+func asString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(j), nil
+}
+
+// asTime converts time.Time or time string (RFC3339 or layouts supported by Infer) to time.Time.
+func asTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if t, ok := Infer(v).(time.Time); ok {
+			return t, nil
+		}
+
+		return time.Time{}, fmt.Errorf("invalid time %q, RFC3339 expected", v)
+	}
+
+	return time.Time{}, fmt.Errorf("unexpected type %T, string or time.Time expected", v)
+}
+
+// asDuration converts time.Duration or duration string (e.g. "-1h30m") to time.Duration.
+func asDuration(v interface{}) (time.Duration, error) {
+	switch v := v.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("parsing duration: %w", err)
+		}
+
+		return d, nil
+	}
+
+	return 0, fmt.Errorf("unexpected type %T, string or time.Duration expected", v)
+}
+
+// floatToInt converts float to signed integer of bit size, if it is a whole number in range of that size.
+func floatToInt(f float64, bits int) (int64, bool) {
+	limit := math.Ldexp(1, bits-1)
+	if f != math.Trunc(f) || f < -limit || f >= limit {
+		return 0, false
+	}
+
+	return int64(f), true
+}
+
+// floatToUint converts float to unsigned integer of bit size, if it is a whole number in range of that size.
+func floatToUint(f float64, bits int) (uint64, bool) {
+	if f != math.Trunc(f) || f < 0 || f >= math.Ldexp(1, bits) {
+		return 0, false
+	}
+
+	return uint64(f), true
+}
@@ -174,3 +174,78 @@ Scenario: using var factory
 	// Output:
 	// creating user John Doe 2023-05-22 09:38:00 +0000 UTC
 }
+
+func ExampleSteps_AddFunc() {
+	vs := &vars.Steps{}
+
+	// Arguments are converted to parameter types, RFC3339 string is converted to time.Time.
+	vs.AddFunc("newUserID", func(ctx context.Context, name string, registeredAt time.Time) (int, error) {
+		fmt.Println("creating user", name, registeredAt)
+
+		// Return relevant value, for example user id.
+		return 123, nil
+	})
+
+	s := godog.TestSuite{}
+
+	s.ScenarioInitializer = func(sc *godog.ScenarioContext) {
+		vs.Register(sc)
+	}
+
+	s.Options = &godog.Options{
+		Format: "pretty",
+		Output: io.Discard,
+		FeatureContents: []godog.Feature{
+			{
+				Name: "example",
+				Contents: []byte(`
+Feature: example
+Scenario: using typed factory
+   Given variable $myUserID is set to newUserID("John Doe", "2023-05-22T09:38:00Z")
+`),
+			},
+		},
+	}
+
+	s.Run()
+
+	// Output:
+	// creating user John Doe 2023-05-22 09:38:00 +0000 UTC
+}
+
+func ExampleSteps_AddStdFactories() {
+	vs := &vars.Steps{}
+
+	vs.AddStdFactories()
+
+	s := godog.TestSuite{}
+
+	s.ScenarioInitializer = func(sc *godog.ScenarioContext) {
+		vs.Register(sc)
+
+		sc.Step("^I print variable \\$([\\w\\d]+)$", func(ctx context.Context, name string) {
+			fmt.Println(vars.FromContext(ctx)["$"+name])
+		})
+	}
+
+	s.Options = &godog.Options{
+		Format: "pretty",
+		Output: io.Discard,
+		FeatureContents: []godog.Feature{
+			{
+				Name: "example",
+				Contents: []byte(`
+Feature: example
+Scenario: using built-in factories
+   Given variable $token is set to base64(concat("john:", sha256("secret")))
+   Then I print variable $token
+`),
+			},
+		},
+	}
+
+	s.Run()
+
+	// Output:
+	// am9objoyYmI4MGQ1MzdiMWRhM2UzOGJkMzAzNjFhYTg1NTY4NmJkZTBlYWNkNzE2MmZlZjZhMjVmZTk3YmY1MjdhMjVi
+}
@@ -0,0 +1,978 @@
+package vars
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokVar
+	tokGen
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	col  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+
+	return fmt.Sprintf("'%s' at col %d", t.text, t.col)
+}
+
+// operators are sorted so that longer operators are matched first.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "|", "+", "-", "*", "/", "%", "<", ">", "!", "=", "(", ")", "[", "]", "{", "}", ":", ","}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// tokenize splits expression into tokens, columns are 1-based.
+func tokenize(src, varPrefix string) ([]token, error) {
+	var tokens []token
+
+	pos := 0
+
+	for pos < len(src) {
+		c := src[pos]
+
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			pos++
+
+			continue
+		}
+
+		start := pos
+
+		switch {
+		case strings.HasPrefix(src[pos:], varPrefix) && pos+len(varPrefix) < len(src) && isWordByte(src[pos+len(varPrefix)]):
+			pos += len(varPrefix)
+			for pos < len(src) && isWordByte(src[pos]) {
+				pos++
+			}
+
+			tokens = append(tokens, token{kind: tokVar, text: src[start:pos], col: start + 1})
+		case c == '"':
+			end, err := scanString(src, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			pos = end
+			tokens = append(tokens, token{kind: tokString, text: src[start:pos], col: start + 1})
+		case isDigit(c) || (c == '.' && pos+1 < len(src) && isDigit(src[pos+1])):
+			pos = scanNumber(src, pos)
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:pos], col: start + 1})
+		case isWordByte(c):
+			for pos < len(src) && (isWordByte(src[pos]) || src[pos] == '.') {
+				pos++
+			}
+
+			if src[start:pos] == "gen" && pos < len(src) && src[pos] == ':' {
+				pos++
+				for pos < len(src) && (isWordByte(src[pos]) || src[pos] == '-' || src[pos] == '.') {
+					pos++
+				}
+
+				tokens = append(tokens, token{kind: tokGen, text: src[start:pos], col: start + 1})
+
+				continue
+			}
+
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:pos], col: start + 1})
+		default:
+			op := ""
+
+			for _, o := range operators {
+				if strings.HasPrefix(src[pos:], o) {
+					op = o
+
+					break
+				}
+			}
+
+			if op == "" {
+				return nil, fmt.Errorf("unexpected '%c' at col %d", c, pos+1)
+			}
+
+			pos += len(op)
+			tokens = append(tokens, token{kind: tokOp, text: op, col: start + 1})
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, col: len(src) + 1}), nil
+}
+
+func scanString(src string, pos int) (int, error) {
+	for i := pos + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unterminated string at col %d", pos+1)
+}
+
+func scanNumber(src string, pos int) int {
+	for pos < len(src) && (isDigit(src[pos]) || src[pos] == '.') {
+		pos++
+	}
+
+	if pos < len(src) && (src[pos] == 'e' || src[pos] == 'E') {
+		pos++
+
+		if pos < len(src) && (src[pos] == '+' || src[pos] == '-') {
+			pos++
+		}
+
+		for pos < len(src) && isDigit(src[pos]) {
+			pos++
+		}
+	}
+
+	return pos
+}
+
+type (
+	exprNode interface {
+		column() int
+	}
+
+	literalNode struct {
+		tok token
+		val interface{}
+	}
+
+	stringNode struct {
+		tok token
+	}
+
+	varNode struct {
+		tok token
+	}
+
+	genNode struct {
+		tok  token
+		args []exprNode
+	}
+
+	callNode struct {
+		tok  token
+		args []exprNode
+	}
+
+	// namedArgNode is an argument of a call, e.g. name="John".
+	namedArgNode struct {
+		tok token
+		x   exprNode
+	}
+
+	// arrayNode is a JSON-like array literal, e.g. [1, $foo, now()].
+	arrayNode struct {
+		tok   token
+		items []exprNode
+	}
+
+	// objectNode is a JSON-like object literal, e.g. {"id": $id, "at": now()}.
+	objectNode struct {
+		tok    token
+		keys   []stringNode
+		values []exprNode
+	}
+
+	unaryNode struct {
+		tok token
+		x   exprNode
+	}
+
+	binaryNode struct {
+		tok  token
+		x, y exprNode
+	}
+)
+
+func (n literalNode) column() int  { return n.tok.col }
+func (n stringNode) column() int   { return n.tok.col }
+func (n varNode) column() int      { return n.tok.col }
+func (n genNode) column() int      { return n.tok.col }
+func (n callNode) column() int     { return n.tok.col }
+func (n namedArgNode) column() int { return n.tok.col }
+func (n arrayNode) column() int    { return n.tok.col }
+func (n objectNode) column() int   { return n.tok.col }
+func (n unaryNode) column() int    { return n.tok.col }
+func (n binaryNode) column() int   { return n.tok.col }
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseExpr builds syntax tree of an expression.
+func parseExpr(src, varPrefix string) (exprNode, error) {
+	tokens, err := tokenize(src, varPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	p := exprParser{tokens: tokens}
+
+	n, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s", t)
+	}
+
+	return n, nil
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+
+	if t.kind != tokEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *exprParser) expect(op string) error {
+	if t := p.next(); t.kind != tokOp || t.text != op {
+		return fmt.Errorf("unexpected %s, '%s' expected", t, op)
+	}
+
+	return nil
+}
+
+// parsePipeline parses values chained with pipe, e.g. now() | addDuration("-1h") | formatTime("2006-01-02").
+//
+// Pipe has the lowest precedence, each stage is a factory call that receives previous value as first argument.
+func (p *exprParser) parsePipeline() (exprNode, error) {
+	x, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if t := p.peek(); t.kind != tokOp || t.text != "|" {
+			return x, nil
+		}
+
+		p.next()
+
+		t := p.next()
+		if t.kind != tokIdent {
+			return nil, fmt.Errorf("unexpected %s, factory expected after '|'", t)
+		}
+
+		n := callNode{tok: t, args: []exprNode{x}}
+
+		// Stage without arguments can omit parentheses, e.g. $foo | upper.
+		if t := p.peek(); t.kind == tokOp && t.text == "(" {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+
+			n.args = append(n.args, args...)
+		}
+
+		x = n
+	}
+}
+
+// binaryPrecedence lists binary operators by binding power, lowest first.
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryPrecedence) {
+		return p.parseUnary()
+	}
+
+	x, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != tokOp || !hasString(binaryPrecedence[level], t.text) {
+			return x, nil
+		}
+
+		p.next()
+
+		y, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		x = binaryNode{tok: t, x: x, y: y}
+	}
+}
+
+func hasString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if t := p.peek(); t.kind == tokOp && (t.text == "-" || t.text == "!") {
+		p.next()
+
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return unaryNode{tok: t, x: x}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t)
+		}
+
+		return literalNode{tok: t, val: f}, nil
+	case tokString:
+		return stringNode{tok: t}, nil
+	case tokVar:
+		return varNode{tok: t}, nil
+	case tokGen:
+		n := genNode{tok: t}
+
+		if t := p.peek(); t.kind == tokOp && t.text == "(" {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+
+			n.args = args
+		}
+
+		return n, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literalNode{tok: t, val: true}, nil
+		case "false":
+			return literalNode{tok: t, val: false}, nil
+		case "null":
+			return literalNode{tok: t, val: nil}, nil
+		}
+
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+
+		return callNode{tok: t, args: args}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parsePipeline()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+
+			return x, nil
+		case "[":
+			return p.parseArray(t)
+		case "{":
+			return p.parseObject(t)
+		}
+	case tokEOF:
+	}
+
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+// parseArgs parses parenthesized list of arguments of a factory or generator.
+func (p *exprParser) parseArgs() ([]exprNode, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var (
+		args  []exprNode
+		names = map[string]bool{}
+	)
+
+	if t := p.peek(); t.kind == tokOp && t.text == ")" {
+		p.next()
+
+		return args, nil
+	}
+
+	for {
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+
+		if n, ok := arg.(namedArgNode); ok {
+			if names[n.tok.text] {
+				return nil, fmt.Errorf("duplicate argument %s at col %d", n.tok.text, n.tok.col)
+			}
+
+			names[n.tok.text] = true
+		} else if len(names) > 0 {
+			return nil, fmt.Errorf("positional argument after named arguments at col %d", arg.column())
+		}
+
+		args = append(args, arg)
+
+		t := p.next()
+		if t.kind == tokOp && t.text == ")" {
+			return args, nil
+		}
+
+		if t.kind != tokOp || t.text != "," {
+			return nil, fmt.Errorf("unexpected %s, ',' or ')' expected", t)
+		}
+	}
+}
+
+// parseArray parses array literal after opening bracket.
+func (p *exprParser) parseArray(open token) (exprNode, error) {
+	n := arrayNode{tok: open}
+
+	if t := p.peek(); t.kind == tokOp && t.text == "]" {
+		p.next()
+
+		return n, nil
+	}
+
+	for {
+		item, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+
+		n.items = append(n.items, item)
+
+		t := p.next()
+		if t.kind == tokOp && t.text == "]" {
+			return n, nil
+		}
+
+		if t.kind != tokOp || t.text != "," {
+			return nil, fmt.Errorf("unexpected %s, ',' or ']' expected", t)
+		}
+	}
+}
+
+// parseObject parses object literal after opening brace.
+func (p *exprParser) parseObject(open token) (exprNode, error) {
+	n := objectNode{tok: open}
+
+	if t := p.peek(); t.kind == tokOp && t.text == "}" {
+		p.next()
+
+		return n, nil
+	}
+
+	for {
+		k := p.next()
+		if k.kind != tokString {
+			return nil, fmt.Errorf("unexpected %s, object key expected", k)
+		}
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		v, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+
+		n.keys = append(n.keys, stringNode{tok: k})
+		n.values = append(n.values, v)
+
+		t := p.next()
+		if t.kind == tokOp && t.text == "}" {
+			return n, nil
+		}
+
+		if t.kind != tokOp || t.text != "," {
+			return nil, fmt.Errorf("unexpected %s, ',' or '}' expected", t)
+		}
+	}
+}
+
+// parseArg parses positional or named argument, e.g. name="John".
+func (p *exprParser) parseArg() (exprNode, error) {
+	t := p.peek()
+
+	// Identifier is never the last token, there is at least tokEOF after it.
+	if t.kind == tokIdent && p.tokens[p.pos+1].kind == tokOp && p.tokens[p.pos+1].text == "=" {
+		p.pos += 2
+
+		x, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+
+		return namedArgNode{tok: t, x: x}, nil
+	}
+
+	return p.parsePipeline()
+}
+
+func (s *Steps) parseExpr(expr string) (exprNode, error) {
+	varPrefix := s.varPrefix
+	if varPrefix == "" {
+		varPrefix = "$"
+	}
+
+	return parseExpr(expr, varPrefix)
+}
+
+// calculate evaluates an expression with variables, factories and generators.
+func (s *Steps) calculate(ctx context.Context, expr string) (context.Context, interface{}, error) {
+	n, err := s.parseExpr(expr)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	return s.evalExpr(s.PrepareContext(ctx), n)
+}
+
+func (s *Steps) evalExpr(ctx context.Context, n exprNode) (context.Context, interface{}, error) {
+	switch n := n.(type) {
+	case literalNode:
+		return ctx, n.val, nil
+	case stringNode:
+		// Strings are interpolated, "$foo" is replaced with value of any type.
+		_, rv, err := s.Replace(ctx, []byte(n.tok.text))
+		if err != nil {
+			return ctx, nil, fmt.Errorf("replacing vars in %s: %w", n.tok.text, err)
+		}
+
+		var val interface{}
+		if err := json.Unmarshal(rv, &val); err != nil {
+			return ctx, nil, fmt.Errorf("decoding string %s at col %d: %w", n.tok.text, n.tok.col, err)
+		}
+
+		return ctx, val, nil
+	case varNode:
+		_, v := s.Vars(ctx)
+
+		val, found := v.Get(n.tok.text)
+		if !found {
+			return ctx, nil, fmt.Errorf("undefined variable %s at col %d", n.tok.text, n.tok.col)
+		}
+
+		return ctx, val, nil
+	case genNode:
+		ctx, args, err := s.evalArgs(ctx, n.args)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		val, err := s.generate(ctx, n.tok.text[4:], args)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("%w at col %d", err, n.tok.col)
+		}
+
+		return ctx, val, nil
+	case callNode:
+		return s.evalCall(ctx, n)
+	case arrayNode:
+		ctx, items, err := s.evalArgs(ctx, n.items)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		return ctx, items, nil
+	case objectNode:
+		return s.evalObject(ctx, n)
+	case unaryNode:
+		ctx, x, err := s.evalExpr(ctx, n.x)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		val, err := unaryOp(n.tok.text, x)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("%w at col %d", err, n.tok.col)
+		}
+
+		return ctx, val, nil
+	case binaryNode:
+		return s.evalBinary(ctx, n)
+	}
+
+	return ctx, nil, fmt.Errorf("unexpected expression at col %d", n.column())
+}
+
+func (s *Steps) evalCall(ctx context.Context, n callNode) (context.Context, interface{}, error) {
+	f, ok := s.factories[n.tok.text]
+	if !ok {
+		return ctx, nil, fmt.Errorf("unknown factory %s at col %d", n.tok.text, n.tok.col)
+	}
+
+	ctx, args, err := s.evalArgs(ctx, n.args)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	f = s.withMiddlewares(n.tok.text, f)
+
+	ctx, val, err := f(context.WithValue(ctx, factoryNameCtxKey{}, n.tok.text), args...)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("calling %s at col %d: %w", n.tok.text, n.tok.col, err)
+	}
+
+	return ctx, val, nil
+}
+
+func (s *Steps) evalObject(ctx context.Context, n objectNode) (context.Context, interface{}, error) {
+	obj := make(map[string]interface{}, len(n.keys))
+
+	for i, k := range n.keys {
+		var key string
+		if err := json.Unmarshal([]byte(k.tok.text), &key); err != nil {
+			return ctx, nil, fmt.Errorf("decoding key %s at col %d: %w", k.tok.text, k.tok.col, err)
+		}
+
+		ctx, val, err := s.evalExpr(ctx, n.values[i])
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		obj[key] = val
+	}
+
+	return ctx, obj, nil
+}
+
+// evalArgs evaluates arguments of a call, named arguments are passed as trailing NamedArgs.
+func (s *Steps) evalArgs(ctx context.Context, nodes []exprNode) (context.Context, []interface{}, error) {
+	var (
+		args  = make([]interface{}, 0, len(nodes))
+		named NamedArgs
+	)
+
+	for _, a := range nodes {
+		var (
+			arg interface{}
+			err error
+		)
+
+		n, isNamed := a.(namedArgNode)
+		if isNamed {
+			a = n.x
+		}
+
+		ctx, arg, err = s.evalExpr(ctx, a)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		if !isNamed {
+			args = append(args, arg)
+
+			continue
+		}
+
+		if named == nil {
+			named = make(NamedArgs)
+		}
+
+		named[n.tok.text] = arg
+	}
+
+	if named != nil {
+		args = append(args, named)
+	}
+
+	return ctx, args, nil
+}
+
+func (s *Steps) evalBinary(ctx context.Context, n binaryNode) (context.Context, interface{}, error) {
+	ctx, x, err := s.evalExpr(ctx, n.x)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	// Logical operators are short-circuited.
+	if n.tok.text == "&&" || n.tok.text == "||" {
+		xb, ok := x.(bool)
+		if !ok {
+			return ctx, nil, fmt.Errorf("bool expected for %s at col %d, %T received", n.tok.text, n.tok.col, x)
+		}
+
+		if xb == (n.tok.text == "||") {
+			return ctx, xb, nil
+		}
+	}
+
+	ctx, y, err := s.evalExpr(ctx, n.y)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	val, err := binaryOp(n.tok.text, x, y)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("%w at col %d", err, n.tok.col)
+	}
+
+	return ctx, val, nil
+}
+
+func unaryOp(op string, x interface{}) (interface{}, error) {
+	switch op {
+	case "!":
+		if b, ok := x.(bool); ok {
+			return !b, nil
+		}
+	case "-":
+		if d, ok := x.(time.Duration); ok {
+			return -d, nil
+		}
+
+		if f, ok := toFloat(x); ok {
+			return -f, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot apply %s to %T", op, x)
+}
+
+// toFloat converts numeric value to float64, time.Duration is not considered a number.
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+//nolint:cyclop,gocyclo,funlen // Operators are listed in a single switch for readability.
+func binaryOp(op string, x, y interface{}) (interface{}, error) {
+	xf, xNum := toFloat(x)
+	yf, yNum := toFloat(y)
+
+	if xNum && yNum {
+		switch op {
+		case "+":
+			return xf + yf, nil
+		case "-":
+			return xf - yf, nil
+		case "*":
+			return xf * yf, nil
+		case "/":
+			if yf == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+
+			return xf / yf, nil
+		case "%":
+			if yf == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+
+			return math.Mod(xf, yf), nil
+		case "==":
+			return xf == yf, nil
+		case "!=":
+			return xf != yf, nil
+		case "<":
+			return xf < yf, nil
+		case "<=":
+			return xf <= yf, nil
+		case ">":
+			return xf > yf, nil
+		case ">=":
+			return xf >= yf, nil
+		}
+	}
+
+	switch op {
+	case "&&", "||":
+		xb, xok := x.(bool)
+		yb, yok := y.(bool)
+
+		if xok && yok {
+			if op == "&&" {
+				return xb && yb, nil
+			}
+
+			return xb || yb, nil
+		}
+	case "==", "!=":
+		xj, err := json.Marshal(x)
+		if err != nil {
+			return nil, err
+		}
+
+		yj, err := json.Marshal(y)
+		if err != nil {
+			return nil, err
+		}
+
+		return (string(xj) == string(yj)) == (op == "=="), nil
+	case "<", "<=", ">", ">=":
+		if c, ok := compare(x, y); ok {
+			switch op {
+			case "<":
+				return c < 0, nil
+			case "<=":
+				return c <= 0, nil
+			case ">":
+				return c > 0, nil
+			default:
+				return c >= 0, nil
+			}
+		}
+	case "+":
+		switch xv := x.(type) {
+		case string:
+			if yv, ok := y.(string); ok {
+				return xv + yv, nil
+			}
+		case time.Time:
+			if yv, ok := y.(time.Duration); ok {
+				return xv.Add(yv), nil
+			}
+		case time.Duration:
+			switch yv := y.(type) {
+			case time.Duration:
+				return xv + yv, nil
+			case time.Time:
+				return yv.Add(xv), nil
+			}
+		}
+	case "-":
+		switch xv := x.(type) {
+		case time.Time:
+			switch yv := y.(type) {
+			case time.Duration:
+				return xv.Add(-yv), nil
+			case time.Time:
+				return xv.Sub(yv), nil
+			}
+		case time.Duration:
+			if yv, ok := y.(time.Duration); ok {
+				return xv - yv, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("cannot apply %s to %T and %T", op, x, y)
+}
+
+// compare returns -1, 0 or 1 for ordered values of the same type.
+func compare(x, y interface{}) (int, bool) {
+	switch xv := x.(type) {
+	case string:
+		if yv, ok := y.(string); ok {
+			return strings.Compare(xv, yv), true
+		}
+	case time.Time:
+		if yv, ok := y.(time.Time); ok {
+			switch {
+			case xv.Before(yv):
+				return -1, true
+			case xv.After(yv):
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	case time.Duration:
+		if yv, ok := y.(time.Duration); ok {
+			switch {
+			case xv < yv:
+				return -1, true
+			case xv > yv:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+
+	return 0, false
+}
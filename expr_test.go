@@ -0,0 +1,76 @@
+package vars
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpr_errors(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		err  string
+	}{
+		{`1 +`, `unexpected end of expression`},
+		{`(1 + 2`, `unexpected end of expression, ')' expected`},
+		{`foo(1, 2))`, `unexpected ')' at col 10`},
+		{`foo(1 2)`, `unexpected '2' at col 7, ',' or ')' expected`},
+		{`"abc`, `unterminated string at col 1`},
+		{`1 # 2`, `unexpected '#' at col 3`},
+		{`foo`, `unexpected end of expression, '(' expected`},
+		{`foo(a=1, 2)`, `positional argument after named arguments at col 10`},
+		{`foo(a=1, a=2)`, `duplicate argument a at col 10`},
+		{`foo(a=)`, `unexpected ')' at col 7`},
+		{`1 = 2`, `unexpected '=' at col 3`},
+		{`newUserID("a,b", foo(")"), 1))`, `unexpected ')' at col 30`},
+		{`[1, 2`, `unexpected end of expression, ',' or ']' expected`},
+		{`{"a" 1}`, `unexpected '1' at col 6, ':' expected`},
+		{`{a: 1}`, `unexpected 'a' at col 2, object key expected`},
+		{`{"a": 1,}`, `unexpected '}' at col 9, object key expected`},
+		{`1 | 2`, `unexpected '2' at col 5, factory expected after '|'`},
+		{`1 |`, `unexpected end of expression, factory expected after '|'`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := parseExpr(tc.expr, "$")
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestSteps_calculate(t *testing.T) {
+	s := &Steps{}
+
+	ctx := ToContext(context.Background(), "$a", 10)
+
+	for _, tc := range []struct {
+		expr string
+		val  interface{}
+		err  string
+	}{
+		{`$a + 2 * 3`, 16.0, ``},
+		{`($a + 2) * 3`, 36.0, ``},
+		{`$a / 4 > 2 || $b`, true, ``},
+		{`"$a" == 10`, true, ``},
+		{`"a" + "b" != "ab"`, false, ``},
+		{`$a / 0`, nil, `division by zero at col 4`},
+		{`$b`, nil, `undefined variable $b at col 1`},
+		{`"a" - 1`, nil, `cannot apply - to string and float64 at col 5`},
+		{`foo()`, nil, `unknown factory foo at col 1`},
+		{`[1, "a,b", $a, [], {}]`, []interface{}{1.0, "a,b", 10, []interface{}{}, map[string]interface{}{}}, ``},
+		{`{"a": $a * 2, "b)": "(c,d)"}`, map[string]interface{}{"a": 20.0, "b)": "(c,d)"}, ``},
+		{`gen:missing`, nil, `missing generator "missing" at col 1`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, val, err := s.calculate(ctx, tc.expr)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.val, val)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package vars
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// AddFunc registers Go function as a factory with automatic conversion of arguments.
+//
+// Function may accept context.Context as first parameter and may be variadic.
+// Function must return a value, optionally followed by error,
+// or context.Context, value and error, e.g.
+//
+//	func(ctx context.Context, name string, at time.Time) (int, error)
+//
+// Arguments are converted to parameter types: JSON numbers to integers,
+// RFC3339 strings to time.Time, duration strings (e.g. "-1h30m") to time.Duration,
+// JSON objects and arrays to structs, maps and slices.
+// Named arguments, e.g. newUser(name="John", age=30), are passed as an object in the last parameter.
+//
+// Optional Doc describes factory in Catalog, signature is derived from parameter types if not set.
+//
+// AddFunc panics if fn is not a function of supported signature.
+func (s *Steps) AddFunc(name string, fn interface{}, doc ...Doc) {
+	f, signature, err := funcFactory(name, fn)
+	if err != nil {
+		panic(err)
+	}
+
+	d := Doc{}
+	if len(doc) > 0 {
+		d = doc[len(doc)-1]
+	}
+
+	if d.Signature == "" {
+		d.Signature = signature
+	}
+
+	s.AddFactory(name, f, d)
+}
+
+// funcFactory wraps Go function into Factory.
+func funcFactory(name string, fn interface{}) (Factory, string, error) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+
+	if ft.Kind() != reflect.Func {
+		return nil, "", fmt.Errorf("%s: function expected, %T received", name, fn)
+	}
+
+	withCtx := ft.NumIn() > 0 && ft.In(0) == contextType
+
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) != errorType:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+	case ft.NumOut() == 3 && ft.Out(0) == contextType && ft.Out(2) == errorType:
+	default:
+		return nil, "", fmt.Errorf("%s: unsupported results of %s, (value), (value, error) or (context.Context, value, error) expected",
+			name, ft.String())
+	}
+
+	params := make([]reflect.Type, 0, ft.NumIn())
+	for i := 0; i < ft.NumIn(); i++ {
+		params = append(params, ft.In(i))
+	}
+
+	if withCtx {
+		params = params[1:]
+	}
+
+	signature := funcSignature(name, params, ft.IsVariadic())
+
+	return func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		in, err := funcArgs(params, ft.IsVariadic(), args)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("%s %w, expected signature %s", name, err, signature)
+		}
+
+		if withCtx {
+			in = append([]reflect.Value{reflect.ValueOf(ctx)}, in...)
+		}
+
+		var out []reflect.Value
+		if ft.IsVariadic() {
+			out = fv.CallSlice(in)
+		} else {
+			out = fv.Call(in)
+		}
+
+		switch len(out) {
+		case 1:
+			return ctx, out[0].Interface(), nil
+		case 2:
+			err, _ := out[1].Interface().(error) //nolint:errorlint // Type assertion on reflected result.
+
+			return ctx, out[0].Interface(), err
+		default:
+			if c, ok := out[0].Interface().(context.Context); ok && c != nil {
+				ctx = c
+			}
+
+			err, _ := out[2].Interface().(error) //nolint:errorlint // Type assertion on reflected result.
+
+			return ctx, out[1].Interface(), err
+		}
+	}, signature, nil
+}
+
+// funcSignature describes function parameters, e.g. newUserID(string, time.Time).
+func funcSignature(name string, params []reflect.Type, variadic bool) string {
+	names := make([]string, 0, len(params))
+
+	for i, p := range params {
+		if variadic && i == len(params)-1 {
+			names = append(names, "..."+p.Elem().String())
+		} else {
+			names = append(names, p.String())
+		}
+	}
+
+	return name + "(" + strings.Join(names, ", ") + ")"
+}
+
+// funcArgs converts factory arguments to function parameters.
+func funcArgs(params []reflect.Type, variadic bool, args []interface{}) ([]reflect.Value, error) {
+	fixed := len(params)
+	if variadic {
+		fixed--
+	}
+
+	if variadic && len(args) < fixed {
+		return nil, fmt.Errorf("expects at least %d arguments, %d received", fixed, len(args))
+	}
+
+	if !variadic && len(args) != fixed {
+		return nil, fmt.Errorf("expects %d arguments, %d received", fixed, len(args))
+	}
+
+	in := make([]reflect.Value, 0, len(params))
+
+	for i := 0; i < fixed; i++ {
+		v, err := convertArg(args[i], params[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+
+		in = append(in, v)
+	}
+
+	if variadic {
+		elem := params[fixed].Elem()
+		rest := reflect.MakeSlice(params[fixed], 0, len(args)-fixed)
+
+		for i := fixed; i < len(args); i++ {
+			v, err := convertArg(args[i], elem)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d: %w", i+1, err)
+			}
+
+			rest = reflect.Append(rest, v)
+		}
+
+		in = append(in, rest)
+	}
+
+	return in, nil
+}
+
+// convertArg converts factory argument to a value of given type.
+func convertArg(arg interface{}, t reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		switch t.Kind() { //nolint:exhaustive // Other kinds do not accept nil.
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			return reflect.Zero(t), nil
+		default:
+			return reflect.Value{}, fmt.Errorf("cannot use null as %s", t)
+		}
+	}
+
+	if reflect.TypeOf(arg).AssignableTo(t) {
+		return reflect.ValueOf(arg), nil
+	}
+
+	switch t {
+	case timeType:
+		tm, err := asTime(arg)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot use %s as time.Time: %w", canonicalJSON(arg), err)
+		}
+
+		return reflect.ValueOf(tm), nil
+	case durationType:
+		d, err := asDuration(arg)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot use %s as time.Duration: %w", canonicalJSON(arg), err)
+		}
+
+		return reflect.ValueOf(d), nil
+	}
+
+	mismatch := fmt.Errorf("cannot use %s (%T) as %s", canonicalJSON(arg), arg, t)
+
+	switch t.Kind() { //nolint:exhaustive // Other kinds are decoded from JSON.
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, ok := toFloat(arg)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+
+		// Range is checked before conversion, as conversion of out of range float is undefined.
+		i, ok := floatToInt(f, t.Bits())
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+
+		v := reflect.New(t).Elem()
+		v.SetInt(i)
+
+		return v, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, ok := toFloat(arg)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+
+		u, ok := floatToUint(f, t.Bits())
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+
+		v := reflect.New(t).Elem()
+		v.SetUint(u)
+
+		return v, nil
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(arg)
+		if !ok {
+			return reflect.Value{}, mismatch
+		}
+
+		return reflect.ValueOf(f).Convert(t), nil
+	case reflect.String, reflect.Bool:
+		v := reflect.ValueOf(arg)
+		if !v.Type().ConvertibleTo(t) || v.Kind() != t.Kind() {
+			return reflect.Value{}, mismatch
+		}
+
+		return v.Convert(t), nil
+	}
+
+	j, err := json.Marshal(arg)
+	if err != nil {
+		return reflect.Value{}, mismatch
+	}
+
+	v := reflect.New(t)
+	if err := json.Unmarshal(j, v.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("%s: %w", mismatch, err)
+	}
+
+	return v.Elem(), nil
+}
@@ -0,0 +1,97 @@
+package vars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps_AddFunc(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	s := &Steps{}
+
+	s.AddFunc("newUserID", func(ctx context.Context, name string, at time.Time) (int, error) {
+		if name == "" {
+			return 0, errors.New("empty name")
+		}
+
+		return len(name) + at.Hour(), nil
+	})
+	s.AddFunc("addDuration", func(t time.Time, d time.Duration) time.Time { return t.Add(d) })
+	s.AddFunc("describe", func(u user, tags ...string) string {
+		return fmt.Sprintf("%s (%d) %v", u.Name, u.Age, tags)
+	})
+	s.AddFunc("half", func(v uint8) float64 { return float64(v) / 2 })
+	s.AddFunc("id", func(n int64) int64 { return n })
+	s.AddFunc("size", func(n uint64) uint64 { return n })
+
+	ctx := ToContext(context.Background(), "$user", map[string]interface{}{"name": "John", "age": 5})
+
+	for _, tc := range []struct {
+		expr string
+		val  interface{}
+		err  string
+	}{
+		{`newUserID("John", "2023-05-22T10:00:00Z")`, 14, ``},
+		{`addDuration("2023-05-22T10:00:00Z", "-1h")`, time.Date(2023, 5, 22, 9, 0, 0, 0, time.UTC), ``},
+		{`describe($user)`, "John (5) []", ``},
+		{`describe($user, "a", "b")`, "John (5) [a b]", ``},
+		{`half(7)`, 3.5, ``},
+		{`describe(name="Jane", age=7)`, "Jane (7) []", ``},
+		{`newUserID("", "2023-05-22T10:00:00Z")`, nil, `calling newUserID at col 1: empty name`},
+		{`newUserID("John")`, nil, `calling newUserID at col 1: newUserID expects 2 arguments, 1 received, ` +
+			`expected signature newUserID(string, time.Time)`},
+		{`newUserID(1, "2023-05-22T10:00:00Z")`, nil, `calling newUserID at col 1: newUserID argument 1: ` +
+			`cannot use 1 (float64) as string, expected signature newUserID(string, time.Time)`},
+		{`newUserID("John", "yesterday")`, nil, `calling newUserID at col 1: newUserID argument 2: ` +
+			`cannot use "yesterday" as time.Time: invalid time "yesterday", RFC3339 expected, ` +
+			`expected signature newUserID(string, time.Time)`},
+		{`describe()`, nil, `calling describe at col 1: describe expects at least 1 arguments, 0 received, ` +
+			`expected signature describe(vars.user, ...string)`},
+		{`describe($user, 1)`, nil, `calling describe at col 1: describe argument 2: ` +
+			`cannot use 1 (float64) as string, expected signature describe(vars.user, ...string)`},
+		{`half(256)`, nil, `calling half at col 1: half argument 1: ` +
+			`cannot use 256 (float64) as uint8, expected signature half(uint8)`},
+		{`half(1.5)`, nil, `calling half at col 1: half argument 1: ` +
+			`cannot use 1.5 (float64) as uint8, expected signature half(uint8)`},
+		{`id(-9e18)`, int64(-9e18), ``},
+		{`size(1e19)`, uint64(1e19), ``},
+		{`id(1e20)`, nil, `calling id at col 1: id argument 1: ` +
+			`cannot use 100000000000000000000 (float64) as int64, expected signature id(int64)`},
+		{`size(2e19)`, nil, `calling size at col 1: size argument 1: ` +
+			`cannot use 20000000000000000000 (float64) as uint64, expected signature size(uint64)`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, val, err := s.calculate(ctx, tc.expr)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.val, val)
+		})
+	}
+}
+
+func TestSteps_AddFunc_invalid(t *testing.T) {
+	s := &Steps{}
+
+	assert.PanicsWithError(t, "foo: function expected, int received", func() {
+		s.AddFunc("foo", 1)
+	})
+
+	assert.PanicsWithError(t, "foo: unsupported results of func() (int, int), "+
+		"(value), (value, error) or (context.Context, value, error) expected", func() {
+		s.AddFunc("foo", func() (int, int) { return 1, 2 })
+	})
+}
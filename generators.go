@@ -0,0 +1,322 @@
+package vars
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// AddStdGenerators registers built-in generators.
+//
+//   - gen:uuid returns random UUID v4 string,
+//   - gen:ulid returns ULID string with current time and random entropy,
+//   - gen:alphanum(n) returns random string of n latin letters and digits,
+//   - gen:hex(n) returns random string of n hexadecimal digits,
+//   - gen:int(min, max) returns random integer in [min, max] range,
+//   - gen:email returns random email address in example.com domain,
+//   - gen:free-port returns a TCP port that is free on localhost.
+//
+// Generators registered with AddGenerator or AddParamGenerator take precedence over built-in generators with same name.
+func (s *Steps) AddStdGenerators() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.generators == nil {
+		s.generators = make(map[string]GeneratorCtx)
+	}
+
+	for name, g := range map[string]struct {
+		f   func(args ...interface{}) (interface{}, error)
+		doc Doc
+	}{
+		"uuid": {s.genUUID, Doc{Signature: "uuid", Description: "Returns random UUID v4 string."}},
+		"ulid": {s.genULID, Doc{Signature: "ulid", Description: "Returns ULID string with current time and random entropy."}},
+		"alphanum": {s.genAlphanum, Doc{
+			Signature:   "alphanum(length)",
+			Description: "Returns random string of latin letters and digits.",
+			Args:        []ArgDoc{{Name: "length", Description: "number of characters"}},
+			Examples:    []string{"gen:alphanum(7)"},
+		}},
+		"hex": {s.genHex, Doc{
+			Signature:   "hex(length)",
+			Description: "Returns random string of hexadecimal digits.",
+			Args:        []ArgDoc{{Name: "length", Description: "number of characters"}},
+			Examples:    []string{"gen:hex(32)"},
+		}},
+		"int": {s.genInt, Doc{
+			Signature:   "int(min, max)",
+			Description: "Returns random integer in [min, max] range.",
+			Args:        []ArgDoc{{Name: "min", Description: "minimal value"}, {Name: "max", Description: "maximal value"}},
+			Examples:    []string{"gen:int(1, 100)"},
+		}},
+		"email":     {s.genEmail, Doc{Signature: "email", Description: "Returns random email address in example.com domain."}},
+		"free-port": {genFreePort, Doc{Signature: "free-port", Description: "Returns a TCP port that is free on localhost."}},
+	} {
+		if _, ok := s.generators[name]; !ok {
+			f := g.f
+			s.generators[name] = func(_ context.Context, args ...interface{}) (interface{}, error) {
+				return f(args...)
+			}
+			setDoc(&s.generatorDocs, name, []Doc{g.doc})
+		}
+	}
+}
+
+// lockedSource makes random source safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (r *lockedSource) Int63() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.src.Int63()
+}
+
+func (r *lockedSource) Seed(seed int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.src.Seed(seed)
+}
+
+// SeedEnv is the name of env var with random seed for generators, zero value means seed from current time.
+const SeedEnv = "GODOG_VARS_SEED"
+
+// seedOutput receives seed reports.
+var seedOutput io.Writer = os.Stderr
+
+// BindFlags adds command line flags with a prefix, e.g. with "vars." prefix:
+// -vars.seed to set random seed for generators and -vars.refresh-cache to set values of GlobalCacheFile again.
+//
+// Flags have to be bound before parsing, for example in TestMain before flag.Parse.
+func (s *Steps) BindFlags(prefix string, fs *flag.FlagSet) {
+	fs.Int64Var(&s.Seed, prefix+"seed", s.Seed, "random seed for generators, env "+SeedEnv+" or current time is used if zero")
+	fs.BoolVar(&s.RefreshGlobalCache, prefix+"refresh-cache", s.RefreshGlobalCache, "set again values of global variables cache")
+}
+
+// Rand returns a concurrency-safe source of random values seeded with Seed.
+//
+// Generators added with AddGenerator or AddParamGenerator can use it to produce reproducible values.
+// Values are reproducible as long as scenarios run in same order, i.e. without concurrency.
+func (s *Steps) Rand() *rand.Rand {
+	return s.random()
+}
+
+// random returns a source of random values for generators.
+func (s *Steps) random() *rand.Rand {
+	s.rndOnce.Do(func() {
+		s.seed = s.Seed
+
+		if s.seed == 0 {
+			if env := os.Getenv(SeedEnv); env != "" {
+				seed, err := strconv.ParseInt(env, 10, 64)
+				if err != nil {
+					_, _ = fmt.Fprintf(seedOutput, "vars: ignoring invalid %s %q: %v\n", SeedEnv, env, err)
+				}
+
+				s.seed = seed
+			}
+		}
+
+		if s.seed == 0 {
+			s.seed = time.Now().UnixNano()
+		}
+
+		s.rnd = rand.New(&lockedSource{src: rand.NewSource(s.seed)}) //nolint:gosec // Generated values are not meant to be secure.
+
+		atomic.StoreInt32(&s.rndUsed, 1)
+	})
+
+	return s.rnd
+}
+
+// reportSeed prints seed of random values on first failed scenario, so that the failure can be reproduced.
+func (s *Steps) reportSeed(ctx context.Context, _ *godog.Scenario, err error) (context.Context, error) {
+	if err == nil || atomic.LoadInt32(&s.rndUsed) == 0 {
+		return ctx, nil
+	}
+
+	s.seedReported.Do(func() {
+		_, _ = fmt.Fprintf(seedOutput, "vars: random values were generated with seed %d, set %s=%d or Steps.Seed to reproduce\n",
+			s.seed, SeedEnv, s.seed)
+	})
+
+	return ctx, nil
+}
+
+func genNoArgs(name string, args []interface{}) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%s expects no arguments, %d received", name, len(args))
+	}
+
+	return nil
+}
+
+// intArgs converts numeric generator arguments to integers.
+func intArgs(name string, args []interface{}, signature ...string) ([]int, error) {
+	if len(args) != len(signature) {
+		return nil, fmt.Errorf("%s expects %d arguments: %s, %d received",
+			name, len(signature), strings.Join(signature, ", "), len(args))
+	}
+
+	res := make([]int, len(args))
+
+	for i, arg := range args {
+		f, ok := toFloat(arg)
+		if !ok || f != math.Trunc(f) || f < math.MinInt || f >= math.MaxInt {
+			return nil, fmt.Errorf("%s: integer expected for %s, %v received", name, signature[i], arg)
+		}
+
+		res[i] = int(f)
+	}
+
+	return res, nil
+}
+
+func (s *Steps) randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rnd := s.random()
+
+	for i := range b {
+		b[i] = byte(rnd.Intn(256))
+	}
+
+	return b
+}
+
+func (s *Steps) genUUID(args ...interface{}) (interface{}, error) {
+	if err := genNoArgs("uuid", args); err != nil {
+		return nil, err
+	}
+
+	b := s.randomBytes(16)
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4.
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant RFC 4122.
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func (s *Steps) genULID(args ...interface{}) (interface{}, error) {
+	if err := genNoArgs("ulid", args); err != nil {
+		return nil, err
+	}
+
+	ms := uint64(time.Now().UnixMilli())
+	res := make([]byte, 26)
+
+	// 48 bits of timestamp are encoded in 10 chars, 80 bits of entropy in 16 chars.
+	for i := 9; i >= 0; i-- {
+		res[i] = crockfordBase32[ms&31]
+		ms >>= 5
+	}
+
+	rnd := s.random()
+	for i := 10; i < 26; i++ {
+		res[i] = crockfordBase32[rnd.Intn(32)]
+	}
+
+	return string(res), nil
+}
+
+const alphanum = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func (s *Steps) randomString(charset string, n int) string {
+	rnd := s.random()
+	res := make([]byte, n)
+
+	for i := range res {
+		res[i] = charset[rnd.Intn(len(charset))]
+	}
+
+	return string(res)
+}
+
+func (s *Steps) genAlphanum(args ...interface{}) (interface{}, error) {
+	n, err := intArgs("alphanum", args, "length")
+	if err != nil {
+		return nil, err
+	}
+
+	if n[0] < 0 {
+		return nil, fmt.Errorf("alphanum: length must not be negative, %d received", n[0])
+	}
+
+	return s.randomString(alphanum, n[0]), nil
+}
+
+func (s *Steps) genHex(args ...interface{}) (interface{}, error) {
+	n, err := intArgs("hex", args, "length")
+	if err != nil {
+		return nil, err
+	}
+
+	if n[0] < 0 {
+		return nil, fmt.Errorf("hex: length must not be negative, %d received", n[0])
+	}
+
+	return s.randomString("0123456789abcdef", n[0]), nil
+}
+
+func (s *Steps) genInt(args ...interface{}) (interface{}, error) {
+	r, err := intArgs("int", args, "min", "max")
+	if err != nil {
+		return nil, err
+	}
+
+	if r[1] < r[0] {
+		return nil, fmt.Errorf("int: max %d is less than min %d", r[1], r[0])
+	}
+
+	// Span of a wide range, e.g. gen:int(-9e18, 9e18), overflows int.
+	span := r[1] - r[0]
+	if span < 0 || span == math.MaxInt {
+		return nil, fmt.Errorf("int: range from %d to %d is too wide", r[0], r[1])
+	}
+
+	return r[0] + s.random().Intn(span+1), nil
+}
+
+func (s *Steps) genEmail(args ...interface{}) (interface{}, error) {
+	if err := genNoArgs("email", args); err != nil {
+		return nil, err
+	}
+
+	return "user-" + s.randomString(alphanum[:26]+alphanum[52:], 10) + "@example.com", nil
+}
+
+func genFreePort(args ...interface{}) (interface{}, error) {
+	if err := genNoArgs("free-port", args); err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("free-port: %w", err)
+	}
+
+	defer l.Close() //nolint:errcheck // Listener is only used to reserve a port.
+
+	addr, ok := l.Addr().(*net.TCPAddr)
+	if !ok {
+		return nil, fmt.Errorf("free-port: unexpected address %s", l.Addr())
+	}
+
+	return addr.Port, nil
+}
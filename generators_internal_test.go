@@ -0,0 +1,54 @@
+package vars
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps_reportSeed(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	prev := seedOutput
+	seedOutput = out
+
+	defer func() { seedOutput = prev }()
+
+	vs := &Steps{Seed: 42}
+	vs.AddStdGenerators()
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Output: bytes.NewBuffer(nil),
+			Strict: true,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Seed.feature",
+					Contents: []byte(`Feature: Seed
+  Scenario: Failed without random values
+    Given variable $a is set to 1
+    Then variable $a equals to 2
+
+  Scenario: Passed with random values
+    Given variable $b is set to gen:int(1, 5)
+
+  Scenario: Failed with random values
+    Given variable $c is set to gen:int(1, 5)
+    Then variable $c equals to 10
+
+  Scenario: Failed again with random values
+    Given variable $d is set to gen:int(1, 5)
+    Then variable $d equals to 10
+`),
+				},
+			},
+		},
+	}
+
+	assert.NotZero(t, suite.Run())
+	assert.Equal(t, "vars: random values were generated with seed 42, set GODOG_VARS_SEED=42 or Steps.Seed to reproduce\n",
+		out.String())
+}
@@ -0,0 +1,95 @@
+package vars_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generatedValues(t *testing.T, vs *vars.Steps) []interface{} {
+	t.Helper()
+
+	var values []interface{}
+
+	vs.AddStdGenerators()
+	vs.AddGenerator("dice", func() (interface{}, error) {
+		return 1 + vs.Rand().Intn(6), nil
+	})
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: func(sc *godog.ScenarioContext) {
+			vs.Register(sc)
+
+			sc.Step(`^values are collected$`, func(ctx context.Context) {
+				for _, name := range []string{"$uuid", "$code", "$num", "$dice"} {
+					values = append(values, vars.FromContext(ctx)[name])
+				}
+			})
+		},
+		Options: &godog.Options{
+			Format: "progress",
+			Strict: true,
+			FeatureContents: []godog.Feature{{
+				Name: "Seeded.feature",
+				Contents: []byte(`Feature: Seeded
+  Scenario: Generating values
+    When variables are set to values
+      | $uuid | gen:uuid           |
+      | $code | gen:alphanum(12)   |
+      | $num  | gen:int(1, 100000) |
+      | $dice | gen:dice           |
+    Then values are collected
+`),
+			}},
+		},
+	}
+
+	require.Zero(t, suite.Run())
+	require.Len(t, values, 4)
+
+	return values
+}
+
+func TestSteps_Seed(t *testing.T) {
+	v1 := generatedValues(t, &vars.Steps{Seed: 42})
+	v2 := generatedValues(t, &vars.Steps{Seed: 42})
+	v3 := generatedValues(t, &vars.Steps{Seed: 43})
+
+	assert.Equal(t, v1, v2)
+	assert.NotEqual(t, fmt.Sprint(v1), fmt.Sprint(v3))
+
+	t.Setenv(vars.SeedEnv, "42")
+
+	assert.Equal(t, v1, generatedValues(t, &vars.Steps{}))
+}
+
+func TestSteps_AddStdGenerators_errors(t *testing.T) {
+	vs := &vars.Steps{}
+	vs.AddStdGenerators()
+
+	for _, tc := range []struct {
+		expr string
+		err  string
+	}{
+		{`gen:alphanum(-1)`, `alphanum: length must not be negative, -1 received`},
+		{`gen:hex(-1)`, `hex: length must not be negative, -1 received`},
+		{`gen:hex(1e20)`, `hex: integer expected for length, 1e+20 received`},
+		{`gen:int(5, 1)`, `int: max 1 is less than min 5`},
+		{`gen:int(-9e18, 9e18)`, `int: range from -9000000000000000000 to 9000000000000000000 is too wide`},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, _, err := vs.ReplaceString(context.Background(), `${`+tc.expr+`}`)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+
+	_, res, err := vs.ReplaceString(context.Background(), `${gen:int(-4e18, 4e18)}`)
+	require.NoError(t, err)
+	assert.NotEmpty(t, res)
+}
@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"sort"
 
 	"github.com/bool64/shared"
 	"github.com/cucumber/godog"
@@ -143,7 +142,23 @@ func (s *Steps) ReplaceString(ctx context.Context, body string) (context.Context
 //
 // This function can help to interpolate variables into predefined templates.
 // It is generally used to prepare `expected` value.
+//
+// With non-nil Steps, ${expr} templates are evaluated with registered factories and generators,
+// e.g. {"id": "${newUserID(\"x\")}", "nonce": "${gen:uuid}"}.
 func (s *Steps) Replace(ctx context.Context, body []byte) (context.Context, []byte, error) {
+	if s != nil && bytes.Contains(body, []byte("${")) {
+		var err error
+
+		if ctx, body, err = s.replaceTemplates(ctx, body); err != nil {
+			return ctx, nil, err
+		}
+	}
+
+	return s.replaceVars(ctx, body)
+}
+
+// replaceVars replaces vars in bytes slice.
+func (s *Steps) replaceVars(ctx context.Context, body []byte) (context.Context, []byte, error) {
 	var err error
 
 	if json5.Valid(body) {
@@ -171,14 +186,7 @@ func (s *Steps) Replace(ctx context.Context, body []byte) (context.Context, []by
 		body = bytes.ReplaceAll(body, []byte(`"`+k+`"`), jv)
 	}
 
-	sort.Slice(varNames, func(i, j int) bool {
-		// Compare lengths first (longer comes first)
-		if len(varNames[i]) != len(varNames[j]) {
-			return len(varNames[i]) > len(varNames[j])
-		}
-		// If lengths are equal, sort alphabetically
-		return varNames[i] < varNames[j]
-	})
+	sortVarNames(varNames)
 
 	for _, k := range varNames {
 		jv := varJV[k]
@@ -0,0 +1,110 @@
+package vars
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// AssertJSONSchema validates payload against JSON schema with vars interpolated.
+func AssertJSONSchema(ctx context.Context, schema, received []byte) (context.Context, error) {
+	var v *Steps
+
+	return v.AssertJSONSchema(ctx, schema, received)
+}
+
+// AssertJSONSchemaFile validates payload against JSON schema from file with vars interpolated.
+func AssertJSONSchemaFile(ctx context.Context, filePath string, received []byte) (context.Context, error) {
+	var v *Steps
+
+	return v.AssertJSONSchemaFile(ctx, filePath, received)
+}
+
+// AssertJSONSchema validates payload against JSON schema with vars interpolated.
+//
+// Schema can be defined as JSON or JSON5, all schema violations are reported in error.
+func (s *Steps) AssertJSONSchema(ctx context.Context, schema, received []byte) (context.Context, error) {
+	return s.assertJSONSchema(ctx, "schema.json", schema, received)
+}
+
+// AssertJSONSchemaFile validates payload against JSON schema from file with vars interpolated.
+//
+// It works same as AssertJSONSchema using a file for schema, relative references are resolved from file location.
+func (s *Steps) AssertJSONSchemaFile(ctx context.Context, filePath string, received []byte) (context.Context, error) {
+	schema, err := os.ReadFile(filePath) //nolint // File inclusion via variable during tests.
+	if err != nil {
+		return ctx, err
+	}
+
+	return s.assertJSONSchema(ctx, filePath, schema, received)
+}
+
+func (s *Steps) assertJSONSchema(ctx context.Context, location string, schema, received []byte) (context.Context, error) {
+	ctx, schema, err := s.Replace(ctx, schema)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to replace vars in JSON schema: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+
+	if err := c.AddResource(location, bytes.NewReader(schema)); err != nil {
+		return ctx, fmt.Errorf("failed to load JSON schema: %w", err)
+	}
+
+	sch, err := c.Compile(location)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to compile JSON schema: %w", err)
+	}
+
+	var rcv interface{}
+	if err := json.Unmarshal(received, &rcv); err != nil {
+		return ctx, fmt.Errorf("failed to unmarshal received value: %w", err)
+	}
+
+	err = sch.Validate(rcv)
+	if err == nil {
+		return ctx, nil
+	}
+
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return ctx, err
+	}
+
+	violations := schemaViolations(nil, ve)
+
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].InstanceLocation != violations[j].InstanceLocation {
+			return violations[i].InstanceLocation < violations[j].InstanceLocation
+		}
+
+		return violations[i].Message < violations[j].Message
+	})
+
+	lines := make([]string, 0, len(violations))
+	for _, v := range violations {
+		lines = append(lines, "#"+v.InstanceLocation+": "+v.Message)
+	}
+
+	return ctx, fmt.Errorf("JSON schema validation failed:\n%s", strings.Join(lines, "\n"))
+}
+
+// schemaViolations collects leaf validation errors that point to violating values.
+func schemaViolations(violations []*jsonschema.ValidationError, ve *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(ve.Causes) == 0 {
+		return append(violations, ve)
+	}
+
+	for _, c := range ve.Causes {
+		violations = schemaViolations(violations, c)
+	}
+
+	return violations
+}
@@ -0,0 +1,46 @@
+package vars_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertJSONSchema(t *testing.T) {
+	ctx := vars.ToContext(context.Background(), "$min", 10)
+
+	schema := []byte(`{
+		"type":"object",
+		"required":["id","name"],
+		"properties":{
+			"id":{"type":"integer","minimum":"$min"},
+			"tags":{"type":"array","items":{"type":"string"}}
+		}
+	}`)
+
+	_, err := vars.AssertJSONSchema(ctx, schema, []byte(`{"id":12,"name":"John","tags":["a"]}`))
+	require.NoError(t, err)
+
+	_, err = vars.AssertJSONSchema(ctx, schema, []byte(`{"id":5,"tags":["a",1,true]}`))
+	require.Error(t, err)
+	assert.Equal(t, `JSON schema validation failed:
+#: missing properties: 'name'
+#/id: must be >= 10 but found 5
+#/tags/1: expected string, but got number
+#/tags/2: expected string, but got boolean`, err.Error())
+}
+
+func TestAssertJSONSchemaFile(t *testing.T) {
+	ctx := vars.ToContext(context.Background(), "$name", "John")
+
+	_, err := vars.AssertJSONSchemaFile(ctx, "_testdata/schemas/user.json", []byte(`{"id":1,"name":"John","tags":[]}`))
+	require.NoError(t, err)
+
+	_, err = vars.AssertJSONSchemaFile(ctx, "_testdata/schemas/user.json", []byte(`{"id":0,"name":"Jane","tags":[]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "#/id: must be >= 1 but found 0")
+	assert.Contains(t, err.Error(), "#/name: value must be \"John\"")
+}
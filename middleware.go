@@ -0,0 +1,211 @@
+package vars
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FactoryMiddleware wraps factory calls, e.g. to add timeouts, retries or logging.
+type FactoryMiddleware func(next Factory) Factory
+
+type factoryMiddleware struct {
+	mw    FactoryMiddleware
+	names map[string]bool
+}
+
+type factoryNameCtxKey struct{}
+
+// FactoryName returns name of currently called factory, it is available in context of factory and middleware.
+func FactoryName(ctx context.Context) string {
+	name, _ := ctx.Value(factoryNameCtxKey{}).(string)
+
+	return name
+}
+
+// UseFactoryMiddleware adds middleware to calls of factories with given names, or to all factories if names are empty.
+//
+// Middlewares are applied in order of addition, first added middleware is the outermost.
+func (s *Steps) UseFactoryMiddleware(mw FactoryMiddleware, names ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := factoryMiddleware{mw: mw}
+
+	if len(names) > 0 {
+		m.names = make(map[string]bool, len(names))
+
+		for _, name := range names {
+			m.names[name] = true
+		}
+	}
+
+	s.middlewares = append(s.middlewares, m)
+}
+
+// withMiddlewares wraps factory with middlewares that apply to its name.
+func (s *Steps) withMiddlewares(name string, f Factory) Factory {
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		m := s.middlewares[i]
+
+		if m.names == nil || m.names[name] {
+			f = m.mw(f)
+		}
+	}
+
+	return f
+}
+
+// detachedContext keeps values of a context, but not its cancellation.
+type detachedContext struct {
+	context.Context
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) { return c.parent.Deadline() }
+func (c detachedContext) Done() <-chan struct{}       { return c.parent.Done() }
+func (c detachedContext) Err() error                  { return c.parent.Err() }
+
+// FactoryTimeout limits duration of a factory call with context deadline.
+//
+// Factory that does not respect context cancellation is abandoned when timeout expires,
+// cleanups registered by abandoned call of CleanupFactory are run as soon as the call returns.
+func FactoryTimeout(timeout time.Duration) FactoryMiddleware {
+	return func(next Factory) Factory {
+		return func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+			tctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			// Cleanups of the call are collected separately to release resources of abandoned call.
+			parent, _ := ctx.Value(cleanupsCtxKey{}).(*cleanups)
+			call := &cleanups{}
+
+			if parent != nil {
+				tctx = context.WithValue(tctx, cleanupsCtxKey{}, call)
+			}
+
+			type result struct {
+				ctx context.Context
+				val interface{}
+				err error
+			}
+
+			done := make(chan result, 1)
+
+			go func() {
+				rctx, val, err := next(tctx, args...)
+				done <- result{ctx: rctx, val: val, err: err}
+			}()
+
+			select {
+			case r := <-done:
+				// Resulting context should outlive the timeout.
+				if r.ctx == tctx {
+					r.ctx = ctx
+				} else {
+					r.ctx = detachedContext{Context: r.ctx, parent: ctx}
+				}
+
+				if parent != nil {
+					call.moveTo(parent)
+
+					r.ctx = context.WithValue(r.ctx, cleanupsCtxKey{}, parent)
+				}
+
+				return r.ctx, r.val, r.err
+			case <-tctx.Done():
+				if parent != nil {
+					go func() {
+						<-done
+						call.run(context.Background())
+					}()
+				}
+
+				return ctx, nil, fmt.Errorf("factory %s timed out after %s: %w", FactoryName(ctx), timeout, tctx.Err())
+			}
+		}
+	}
+}
+
+// FactoryRetry repeats failed factory calls up to attempts times in total.
+//
+// Delay between attempts starts with backoff and doubles after each attempt.
+// If retryIf is not nil, only errors that it accepts are retried.
+func FactoryRetry(attempts int, backoff time.Duration, retryIf func(err error) bool) FactoryMiddleware {
+	return func(next Factory) Factory {
+		return func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+			delay := backoff
+
+			for i := 1; ; i++ {
+				rctx, val, err := next(ctx, args...)
+				if err == nil || i >= attempts || (retryIf != nil && !retryIf(err)) {
+					return rctx, val, err
+				}
+
+				t := time.NewTimer(delay)
+
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+
+					return ctx, nil, fmt.Errorf("%w, retry canceled: %v", err, ctx.Err()) //nolint:errorlint // Last error is wrapped.
+				}
+
+				delay *= 2
+			}
+		}
+	}
+}
+
+// FactoryConcurrencyLimit limits number of concurrent calls of factories, e.g. to protect a connection pool.
+//
+// Limit is shared by all factories that use same middleware instance, it panics if limit is less than 1.
+func FactoryConcurrencyLimit(limit int) FactoryMiddleware {
+	if limit < 1 {
+		panic(fmt.Errorf("factory concurrency limit must be positive, %d received", limit))
+	}
+
+	sem := make(chan struct{}, limit)
+
+	return func(next Factory) Factory {
+		return func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx, nil, fmt.Errorf("waiting for factory %s: %w", FactoryName(ctx), ctx.Err())
+			}
+
+			defer func() { <-sem }()
+
+			return next(ctx, args...)
+		}
+	}
+}
+
+// FactoryLogger logs factory calls with arguments, results and durations.
+func FactoryLogger(logf func(format string, args ...interface{})) FactoryMiddleware {
+	return func(next Factory) Factory {
+		return func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+			start := time.Now()
+			name := FactoryName(ctx)
+
+			a := make([]string, 0, len(args))
+			for _, arg := range args {
+				a = append(a, canonicalJSON(arg))
+			}
+
+			rctx, val, err := next(ctx, args...)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				logf("factory %s(%s) failed in %s: %v", name, strings.Join(a, ", "), elapsed, err)
+			} else {
+				logf("factory %s(%s) returned %s in %s", name, strings.Join(a, ", "), canonicalJSON(val), elapsed)
+			}
+
+			return rctx, val, err
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package vars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSteps_UseFactoryMiddleware(t *testing.T) {
+	s := &Steps{}
+
+	var (
+		calls int32
+		logs  []string
+	)
+
+	s.AddFunc("flaky", func(v float64) (float64, error) {
+		if atomic.AddInt32(&calls, 1)%3 != 0 {
+			return 0, errors.New("temporary failure")
+		}
+
+		return v * 2, nil
+	})
+	s.AddFunc("broken", func() (int, error) { return 0, errors.New("permanent failure") })
+	s.AddFunc("slow", func() string {
+		time.Sleep(200 * time.Millisecond)
+
+		return "done"
+	})
+	s.AddFunc("fast", func() string { return "ok" })
+
+	s.UseFactoryMiddleware(FactoryLogger(func(format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}))
+	s.UseFactoryMiddleware(FactoryRetry(3, time.Millisecond, nil), "flaky", "broken")
+	s.UseFactoryMiddleware(FactoryTimeout(10*time.Millisecond), "slow", "fast")
+
+	_, val, err := s.calculate(context.Background(), `flaky(21)`)
+	require.NoError(t, err)
+	assert.Equal(t, 42.0, val)
+	assert.Equal(t, int32(3), calls)
+
+	_, val, err = s.calculate(context.Background(), `flaky(1) + flaky(2)`)
+	require.NoError(t, err)
+	assert.Equal(t, 6.0, val)
+
+	_, _, err = s.calculate(context.Background(), `1 + broken()`)
+	assert.EqualError(t, err, "calling broken at col 5: permanent failure")
+
+	_, _, err = s.calculate(context.Background(), `slow()`)
+	assert.EqualError(t, err, "calling slow at col 1: factory slow timed out after 10ms: context deadline exceeded")
+
+	ctx, val, err := s.calculate(context.Background(), `fast()`)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", val)
+	assert.NoError(t, ctx.Err())
+
+	require.Len(t, logs, 6)
+	assert.True(t, strings.HasPrefix(logs[0], "factory flaky(21) returned 42 in "), logs[0])
+	assert.True(t, strings.HasPrefix(logs[1], "factory flaky(1) returned 2 in "), logs[1])
+	assert.True(t, strings.HasPrefix(logs[2], "factory flaky(2) returned 4 in "), logs[2])
+	assert.True(t, strings.HasPrefix(logs[3], "factory broken() failed in "), logs[3])
+	assert.True(t, strings.HasSuffix(logs[3], ": permanent failure"), logs[3])
+	assert.True(t, strings.HasPrefix(logs[4], "factory slow() failed in "), logs[4])
+	assert.True(t, strings.HasPrefix(logs[5], "factory fast() returned \"ok\" in "), logs[5])
+}
+
+func TestFactoryRetry_retryIf(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	calls := 0
+
+	f := FactoryRetry(5, time.Millisecond, func(err error) bool {
+		return !errors.Is(err, errPermanent)
+	})(func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		calls++
+
+		return ctx, nil, errPermanent
+	})
+
+	_, _, err := f(context.Background())
+	assert.Equal(t, errPermanent, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFactoryTimeout_abandoned(t *testing.T) {
+	f := FactoryTimeout(10 * time.Millisecond)(func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		time.Sleep(100 * time.Millisecond)
+
+		return ctx, 1, nil
+	})
+
+	ctx := context.WithValue(context.Background(), factoryNameCtxKey{}, "sleepy")
+
+	_, _, err := f(ctx)
+	assert.EqualError(t, err, "factory sleepy timed out after 10ms: context deadline exceeded")
+}
+
+func TestFactoryConcurrencyLimit(t *testing.T) {
+	var (
+		running, maxRunning int32
+		wg                  sync.WaitGroup
+	)
+
+	f := FactoryConcurrencyLimit(2)(func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		return ctx, nil, nil
+	})
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _, err := f(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maxRunning)
+}
+
+func TestFactoryConcurrencyLimit_invalid(t *testing.T) {
+	for _, limit := range []int{0, -1} {
+		assert.PanicsWithError(t, fmt.Sprintf("factory concurrency limit must be positive, %d received", limit), func() {
+			FactoryConcurrencyLimit(limit)
+		})
+	}
+}
+
+func TestFactoryTimeout_cleanups(t *testing.T) {
+	s := &Steps{}
+
+	var released int32
+
+	s.AddCleanupFactory("conn", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+		d, _ := args[0].(float64)
+		time.Sleep(time.Duration(d) * time.Millisecond)
+
+		return ctx, d, func(ctx context.Context) error {
+			atomic.AddInt32(&released, 1)
+
+			return nil
+		}, nil
+	})
+	s.UseFactoryMiddleware(FactoryTimeout(20 * time.Millisecond))
+
+	c := &cleanups{}
+	ctx := context.WithValue(context.Background(), cleanupsCtxKey{}, c)
+	ctx = context.WithValue(ctx, varNameCtxKey{}, "$conn")
+
+	// Cleanup of call that finished in time is moved to the scope.
+	ctx, _, err := s.withMiddlewares("conn", s.factories["conn"])(context.WithValue(ctx, factoryNameCtxKey{}, "conn"), float64(1))
+	require.NoError(t, err)
+	assert.Equal(t, c, ctx.Value(cleanupsCtxKey{}))
+	assert.True(t, c.has("$conn"))
+
+	// Cleanup of abandoned call is run when the call returns.
+	_, _, err = s.withMiddlewares("conn", s.factories["conn"])(context.WithValue(ctx, factoryNameCtxKey{}, "conn"), float64(50))
+	require.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&released))
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&released) == 1 }, time.Second, 5*time.Millisecond)
+
+	assert.Empty(t, c.run(ctx))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&released))
+}
@@ -0,0 +1,196 @@
+package vars
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Names of shared scopes for expiration of variables.
+const globalScope = "global"
+
+func featureScope(uri string) string { return "feature " + uri }
+func ruleScope(rule string) string   { return "rule " + rule }
+func tagScope(tag string) string     { return "tag " + tag }
+
+type expiryKey struct {
+	scope string
+	name  string
+}
+
+// setExpiry starts lifetime of a variable set once in a scope at the time of value creation.
+// Must be called with s.mu locked.
+func (s *Steps) setExpiry(scope, name string, createdAt time.Time) {
+	if s.OnceTTL <= 0 {
+		return
+	}
+
+	if s.expiresAt == nil {
+		s.expiresAt = make(map[expiryKey]time.Time)
+	}
+
+	s.expiresAt[expiryKey{scope: scope, name: name}] = createdAt.Add(s.OnceTTL)
+}
+
+// expire removes variables with expired lifetime from a scope.
+// Must be called with s.mu locked.
+func (s *Steps) expire(scope string, vals map[string]interface{}) {
+	if len(s.expiresAt) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	for name := range vals {
+		k := expiryKey{scope: scope, name: name}
+
+		if at, ok := s.expiresAt[k]; ok && now.After(at) {
+			delete(vals, name)
+			delete(s.expiresAt, k)
+		}
+	}
+}
+
+// resetExpiry forgets lifetimes of variables in scopes that match.
+// Must be called with s.mu locked.
+func (s *Steps) resetExpiry(match func(scope string) bool) {
+	for k := range s.expiresAt {
+		if match(k.scope) {
+			delete(s.expiresAt, k)
+		}
+	}
+}
+
+// ResetGlobals removes variables that were set once globally or once for a tag.
+//
+// It allows reusing Steps in multiple suite runs without leaking values between them.
+// Cleanups of removed variables are not called, see Cleanup.
+func (s *Steps) ResetGlobals() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetGlobals()
+}
+
+// Must be called with s.mu locked.
+func (s *Steps) resetGlobals() {
+	// Maps are cleared in place, as they can be referenced by running scenarios.
+	for name := range s.globalVars {
+		delete(s.globalVars, name)
+	}
+
+	for _, tv := range s.tagVars {
+		for name := range tv {
+			delete(tv, name)
+		}
+	}
+
+	s.resetExpiry(func(scope string) bool {
+		return scope == globalScope || strings.HasPrefix(scope, tagScope(""))
+	})
+
+	// Cache file is read again, so that values stored by another run can be used.
+	s.globalCache = nil
+	s.cacheRefreshed = false
+}
+
+// Reset removes variables of all shared scopes and forgets collected rules of features.
+//
+// It allows reusing Steps in multiple suite runs from a clean state, unlike ResetGlobals that keeps
+// variables of features and rules. Pending cleanups are called before, their errors are returned, see Cleanup.
+func (s *Steps) Reset() error {
+	err := s.Cleanup()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetGlobals()
+
+	for uri := range s.featureVars {
+		s.resetFeature(uri)
+	}
+
+	for _, rv := range s.ruleVars {
+		for name := range rv {
+			delete(rv, name)
+		}
+	}
+
+	s.expiresAt = nil
+	s.rules = nil
+	s.rulesUsed = nil
+
+	return err
+}
+
+// ResetFeature removes variables that were set once in a feature or in its rules.
+//
+// Feature is identified by URI, same as godog.Scenario Uri.
+// Cleanups of removed variables are not called, they are called after suite, see Cleanup.
+func (s *Steps) ResetFeature(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetFeature(uri)
+}
+
+// Must be called with s.mu locked.
+func (s *Steps) resetFeature(uri string) {
+	for name := range s.featureVars[uri] {
+		delete(s.featureVars[uri], name)
+	}
+
+	rulePrefix := uri + ":"
+
+	for rule, rv := range s.ruleVars {
+		if !strings.HasPrefix(rule, rulePrefix) {
+			continue
+		}
+
+		for name := range rv {
+			delete(rv, name)
+		}
+	}
+
+	s.resetExpiry(func(scope string) bool {
+		return scope == featureScope(uri) || strings.HasPrefix(scope, ruleScope(rulePrefix))
+	})
+}
+
+// featureVarsAreReset removes variables of current feature and its rules from the feature and from the scenario.
+func (s *Steps) featureVarsAreReset(ctx context.Context) (context.Context, error) {
+	sc, ok := ScenarioFromContext(ctx)
+	if !ok {
+		return ctx, errors.New("BUG: missing scenario in context")
+	}
+
+	var names []string
+
+	s.mu.Lock()
+
+	for name := range s.featureVars[sc.Uri] {
+		names = append(names, name)
+	}
+
+	for rule, rv := range s.ruleVars {
+		if strings.HasPrefix(rule, sc.Uri+":") {
+			for name := range rv {
+				names = append(names, name)
+			}
+		}
+	}
+
+	s.resetFeature(sc.Uri)
+	s.mu.Unlock()
+
+	var err error
+
+	for _, name := range names {
+		if ctx, err = s.Unset(ctx, name); err != nil {
+			return ctx, err
+		}
+	}
+
+	return ctx, nil
+}
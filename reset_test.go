@@ -0,0 +1,207 @@
+package vars_test
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSteps_ResetGlobals(t *testing.T) {
+	var seq int64
+
+	vs := &vars.Steps{}
+	vs.AddGenerator("seq", func() (interface{}, error) {
+		return atomic.AddInt64(&seq, 1), nil
+	})
+
+	run := func(expected int) int {
+		suite := godog.TestSuite{
+			ScenarioInitializer: vs.Register,
+			Options: &godog.Options{
+				Format: "progress",
+				Strict: true,
+				FeatureContents: []godog.Feature{
+					{
+						Name: "Globals.feature",
+						Contents: []byte(`Feature: Globals
+  @billing
+  Scenario: Setting globals
+    Given variables are set to values once globally
+      | $global | gen:seq |
+    And variables are set to values once for tag @billing
+      | $tagged | gen:seq |
+    Then variables are equal to values
+      | $global | ` + strconv.Itoa(expected) + ` |
+      | $tagged | ` + strconv.Itoa(expected+1) + ` |
+`),
+					},
+				},
+			},
+		}
+
+		return suite.Run()
+	}
+
+	assert.Zero(t, run(1))
+	assert.NotZero(t, run(3), "globals are reused without reset")
+
+	vs.ResetGlobals()
+	assert.Zero(t, run(3))
+	assert.Equal(t, int64(4), atomic.LoadInt64(&seq))
+}
+
+func TestSteps_Reset(t *testing.T) {
+	var seq int64
+
+	vs := &vars.Steps{}
+	vs.AddGenerator("seq", func() (interface{}, error) {
+		return atomic.AddInt64(&seq, 1), nil
+	})
+
+	cleaned := 0
+	vs.AddCleanupFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+		return ctx, 1, func(ctx context.Context) error {
+			cleaned++
+
+			return nil
+		}, nil
+	})
+
+	run := func(expected int) int {
+		opts := &godog.Options{
+			Format: "progress",
+			Strict: true,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Scopes.feature",
+					Contents: []byte(`Feature: Scopes
+  Rule: Billing
+    Scenario: Setting shared values
+      Given variables are set to values once in this feature
+        | $feature | gen:seq |
+        | $user    | newUser() |
+      And variables are set to values once in this rule
+        | $rule | gen:seq |
+      Then variables are equal to values
+        | $feature | ` + strconv.Itoa(expected) + ` |
+        | $rule    | ` + strconv.Itoa(expected+1) + ` |
+`),
+				},
+			},
+		}
+
+		vs.RegisterFormatter(opts)
+
+		suite := godog.TestSuite{
+			TestSuiteInitializer: vs.RegisterSuite,
+			ScenarioInitializer:  vs.Register,
+			Options:              opts,
+		}
+
+		return suite.Run()
+	}
+
+	assert.Zero(t, run(1))
+	assert.Equal(t, 1, cleaned)
+
+	vs.ResetGlobals()
+	assert.NotZero(t, run(3), "feature and rule values are kept by ResetGlobals")
+
+	require.NoError(t, vs.Reset())
+	assert.Zero(t, run(3))
+	assert.Equal(t, int64(4), atomic.LoadInt64(&seq))
+	assert.Equal(t, 2, cleaned)
+}
+
+func TestSteps_featureVarsAreReset(t *testing.T) {
+	vs := &vars.Steps{}
+	calls := 0
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: func(sc *godog.ScenarioContext) {
+			vs.Register(sc)
+
+			sc.Step(`^changes are observed$`, func(ctx context.Context) context.Context {
+				ctx, v := vs.Vars(ctx)
+				v.OnSet(func(key string, val interface{}) { calls++ })
+
+				return ctx
+			})
+		},
+		Options: &godog.Options{
+			Format: "progress",
+			Strict: true,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Reset.feature",
+					Contents: []byte(`Feature: Reset
+  Scenario: Resetting with callbacks
+    Given variables are set to values once in this feature
+      | $feature | 1 |
+    And changes are observed
+    When feature variables are reset
+    And variable $scenario is set to 2
+    Then variable $feature is undefined
+`),
+				},
+			},
+		},
+	}
+
+	assert.Zero(t, suite.Run())
+	assert.Equal(t, 1, calls, "OnSet callbacks are kept after reset")
+}
+
+func TestSteps_OnceTTL(t *testing.T) {
+	var seq int64
+
+	vs := &vars.Steps{OnceTTL: 50 * time.Millisecond}
+	vs.AddGenerator("seq", func() (interface{}, error) {
+		return atomic.AddInt64(&seq, 1), nil
+	})
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: func(sc *godog.ScenarioContext) {
+			vs.Register(sc)
+
+			sc.Step(`^time passes$`, func() { time.Sleep(100 * time.Millisecond) })
+		},
+		Options: &godog.Options{
+			Format: "progress",
+			Strict: true,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "TTL.feature",
+					Contents: []byte(`Feature: TTL
+  Scenario: Setting value
+    Given variables are set to values once in this feature
+      | $value | gen:seq |
+    Then variable $value equals to 1
+
+  Scenario: Reusing value
+    Given variable $value equals to 1
+    And variables are set to values once in this feature
+      | $value | gen:seq |
+    Then variable $value equals to 1
+    And time passes
+
+  Scenario: Expired value
+    Given variable $value is undefined
+    And variables are set to values once in this feature
+      | $value | gen:seq |
+    Then variable $value equals to 2
+`),
+				},
+			},
+		},
+	}
+
+	assert.Zero(t, suite.Run())
+}
@@ -0,0 +1,185 @@
+package vars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cucumber/godog"
+	messages "github.com/cucumber/messages/go/v21"
+)
+
+type rvCtxKey struct{}
+
+// RegisterFormatter adds a formatter to godog options to collect rules of features,
+// it is needed for variables set once in this rule.
+//
+// Rules are not available in godog.Scenario, but features are passed to formatters before their scenarios run.
+// It should be called when options are final, e.g. after flags are parsed, as it appends to Format.
+func (s *Steps) RegisterFormatter(opts *godog.Options) {
+	s.mu.Lock()
+
+	if s.formatter == "" {
+		s.formatter = fmt.Sprintf("vars-rules-%p", s)
+
+		godog.Format(s.formatter, "Collects rules of features for vars.Steps.", func(string, io.Writer) godog.Formatter {
+			return rulesFormatter{s: s}
+		})
+	}
+
+	name := s.formatter
+
+	s.mu.Unlock()
+
+	if opts.Format == "" {
+		opts.Format = "pretty"
+	}
+
+	opts.Format += "," + name
+}
+
+// rulesFormatter collects rules of features and ignores other events.
+type rulesFormatter struct {
+	s *Steps
+}
+
+func (f rulesFormatter) Feature(doc *messages.GherkinDocument, uri string, _ []byte) {
+	f.s.addFeatureRules(doc, uri)
+}
+
+func (rulesFormatter) TestRunStarted()                                                             {}
+func (rulesFormatter) Pickle(*messages.Pickle)                                                     {}
+func (rulesFormatter) Defined(*messages.Pickle, *messages.PickleStep, *godog.StepDefinition)       {}
+func (rulesFormatter) Passed(*messages.Pickle, *messages.PickleStep, *godog.StepDefinition)        {}
+func (rulesFormatter) Skipped(*messages.Pickle, *messages.PickleStep, *godog.StepDefinition)       {}
+func (rulesFormatter) Undefined(*messages.Pickle, *messages.PickleStep, *godog.StepDefinition)     {}
+func (rulesFormatter) Pending(*messages.Pickle, *messages.PickleStep, *godog.StepDefinition)       {}
+func (rulesFormatter) Summary()                                                                    {}
+func (rulesFormatter) Failed(*messages.Pickle, *messages.PickleStep, *godog.StepDefinition, error) {}
+
+// addFeatureRules maps AST node IDs of scenarios in rules of a feature to keys of their rules.
+func (s *Steps) addFeatureRules(doc *messages.GherkinDocument, uri string) {
+	rules := make(map[string]string)
+
+	if doc.Feature != nil {
+		for _, c := range doc.Feature.Children {
+			if c.Rule == nil {
+				continue
+			}
+
+			key := fmt.Sprintf("%s:%d", uri, c.Rule.Location.Line)
+
+			for _, rc := range c.Rule.Children {
+				if rc.Scenario != nil {
+					rules[rc.Scenario.Id] = key
+				}
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rules == nil {
+		s.rules = make(map[string]map[string]string)
+	}
+
+	s.rules[uri] = rules
+}
+
+// ruleOf returns key of a rule of the scenario, or empty string if scenario is not in a rule.
+//
+// Scenario is matched by AST node ID with features collected by formatter, see RegisterFormatter.
+// Must be called with s.mu locked.
+func (s *Steps) ruleOf(sc *godog.Scenario) (string, error) {
+	rules, found := s.rules[sc.Uri]
+	if !found {
+		return "", fmt.Errorf("rules of %s are not collected, use Steps.RegisterFormatter", sc.Uri)
+	}
+
+	if len(sc.AstNodeIds) == 0 {
+		return "", nil
+	}
+
+	return rules[sc.AstNodeIds[0]], nil
+}
+
+// setupRuleVars adds variables of scenario rule to context, if there are any rule variables in the feature.
+// Must be called with s.mu locked.
+func (s *Steps) setupRuleVars(ctx context.Context, sc *godog.Scenario) (context.Context, map[string]interface{}, error) {
+	if !s.rulesUsed[sc.Uri] {
+		return ctx, nil, nil
+	}
+
+	rule, err := s.ruleOf(sc)
+	if err != nil || rule == "" {
+		return ctx, nil, err
+	}
+
+	rv := s.ruleVarsByKey(rule)
+	s.expire(ruleScope(rule), rv)
+
+	return context.WithValue(ctx, rvCtxKey{}, rule), rv, nil
+}
+
+// ruleVarsByKey returns variables of a rule, creating the storage when needed.
+// Must be called with s.mu locked.
+func (s *Steps) ruleVarsByKey(rule string) map[string]interface{} {
+	if s.ruleVars == nil {
+		s.ruleVars = make(map[string]map[string]interface{})
+	}
+
+	rv := s.ruleVars[rule]
+	if rv == nil {
+		rv = make(map[string]interface{})
+		s.ruleVars[rule] = rv
+	}
+
+	return rv
+}
+
+// ruleVarsOf returns key and variables of scenario rule, creating the storage when needed.
+// Must be called with s.mu locked.
+func (s *Steps) ruleVarsOf(ctx context.Context) (string, map[string]interface{}, error) {
+	if rule, ok := ctx.Value(rvCtxKey{}).(string); ok {
+		return rule, s.ruleVarsByKey(rule), nil
+	}
+
+	sc, ok := ScenarioFromContext(ctx)
+	if !ok {
+		return "", nil, errors.New("BUG: missing scenario in context")
+	}
+
+	rule, err := s.ruleOf(sc)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if rule == "" {
+		return "", nil, fmt.Errorf("scenario %q is not in a rule", sc.Name)
+	}
+
+	if s.rulesUsed == nil {
+		s.rulesUsed = make(map[string]bool)
+	}
+
+	s.rulesUsed[sc.Uri] = true
+
+	return rule, s.ruleVarsByKey(rule), nil
+}
+
+func (s *Steps) varsAreSetOnceInThisRule(ctx context.Context, table *godog.Table) (context.Context, error) {
+	ctx, _ = s.Vars(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rule, rv, err := s.ruleVarsOf(ctx)
+	if err != nil {
+		return ctx, err
+	}
+
+	// Rule resources are cleaned up together with resources of its feature.
+	return ctx, s.setOnce(ctx, ruleScope(rule), rv, ctx.Value(featureCleanupsCtxKey{}), table)
+}
@@ -0,0 +1,97 @@
+package vars_test
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps_RegisterFormatter(t *testing.T) {
+	var seq int64
+
+	vs := &vars.Steps{}
+	vs.AddGenerator("seq", func() (interface{}, error) {
+		return atomic.AddInt64(&seq, 1), nil
+	})
+
+	feature := func(name string) godog.Feature {
+		return godog.Feature{
+			Name: name + ".feature",
+			// Rules have same scenarios, so they can only be told apart by AST.
+			Contents: []byte(`Feature: ` + name + `
+  Scenario: Setting rule variable
+    Given variables are set to values once in this rule
+      | $rule | gen:seq |
+
+  Rule: First
+
+    Scenario: Setting rule variable
+      Given variables are set to values once in this rule
+        | $rule | gen:seq |
+
+    Scenario: Setting rule variable
+      Given variables are set to values once in this rule
+        | $rule | gen:seq |
+
+  Rule: Second
+
+    Scenario: Setting rule variable
+      Given variables are set to values once in this rule
+        | $rule | gen:seq |
+`),
+		}
+	}
+
+	out := bytes.NewBuffer(nil)
+	opts := &godog.Options{
+		Format:          "progress",
+		Output:          out,
+		NoColors:        true,
+		Strict:          true,
+		FeatureContents: []godog.Feature{feature("One"), feature("Two")},
+	}
+
+	vs.RegisterFormatter(opts)
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: vs.Register,
+		Options:             opts,
+	}
+
+	assert.Equal(t, 1, suite.Run())
+	assert.Contains(t, out.String(), `scenario "Setting rule variable" is not in a rule`)
+	assert.Contains(t, out.String(), "8 scenarios (6 passed, 2 failed)")
+
+	// Two rules in each of two features.
+	assert.Equal(t, int64(4), atomic.LoadInt64(&seq))
+}
+
+func TestSteps_RegisterFormatter_missing(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	vs := &vars.Steps{}
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: vs.Register,
+		Options: &godog.Options{
+			Format:   "progress",
+			Output:   out,
+			NoColors: true,
+			FeatureContents: []godog.Feature{{
+				Name: "Rules.feature",
+				Contents: []byte(`Feature: Rules
+  Rule: First
+    Scenario: Setting rule variable
+      Given variables are set to values once in this rule
+        | $rule | 1 |
+`),
+			}},
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run())
+	assert.Contains(t, out.String(), "rules of Rules.feature are not collected, use Steps.RegisterFormatter")
+}
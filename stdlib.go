@@ -0,0 +1,315 @@
+package vars
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yalp/jsonpath"
+)
+
+// AddStdFactories registers built-in factories.
+//
+//   - now() returns current time.Time,
+//   - addDuration(time, duration) adds duration (e.g. "-1h30m") to time (time.Time or RFC3339 string),
+//   - formatTime(time, layout) formats time with Go layout, e.g. "2006-01-02",
+//   - concat(values...) joins values as strings,
+//   - upper(string), lower(string) change case,
+//   - base64(string), unbase64(string) encode and decode standard base64,
+//   - sha256(string) returns hex-encoded SHA-256 hash,
+//   - urlencode(string) escapes string for URL query,
+//   - jsonEncode(value) returns JSON string of value, jsonDecode(string) decodes JSON string,
+//   - len(value) returns length of string, array or object,
+//   - string(value) converts value to string, non-string values are JSON encoded,
+//   - jsonpath(value, path) returns value at JSON path, e.g. "$.items[0].id", JSON strings are decoded.
+//
+// Built-in factories accept only positional arguments.
+//
+// Factories registered with AddFactory before or after take precedence over built-in factories with same name.
+func (s *Steps) AddStdFactories() {
+	for name, f := range map[string]struct {
+		f   Factory
+		doc Doc
+	}{
+		"now": {stdNow, Doc{
+			Signature:   "now()",
+			Description: "Returns current time.",
+		}},
+		"addDuration": {stdAddDuration, Doc{
+			Signature:   "addDuration(time, duration)",
+			Description: "Adds duration to time.",
+			Args: []ArgDoc{
+				{Name: "time", Description: "time.Time or RFC3339 string"},
+				{Name: "duration", Description: "Go duration string, e.g. \"-1h30m\""},
+			},
+			Examples: []string{`addDuration(now(), "-10h")`},
+		}},
+		"formatTime": {stdFormatTime, Doc{
+			Signature:   "formatTime(time, layout)",
+			Description: "Formats time with Go layout.",
+			Args: []ArgDoc{
+				{Name: "time", Description: "time.Time or RFC3339 string"},
+				{Name: "layout", Description: "Go time layout, e.g. \"2006-01-02\""},
+			},
+			Examples: []string{`formatTime(now(), "2006-01-02")`},
+		}},
+		"concat": {stdConcat, Doc{
+			Signature:   "concat(values...)",
+			Description: "Joins values as strings, non-string values are JSON encoded.",
+			Examples:    []string{`concat("user-", $id)`},
+		}},
+		"upper":     {stringFactory("upper", strings.ToUpper), stringDoc("upper(string)", "Converts string to upper case.")},
+		"lower":     {stringFactory("lower", strings.ToLower), stringDoc("lower(string)", "Converts string to lower case.")},
+		"base64":    {stringFactory("base64", base64Encode), stringDoc("base64(string)", "Encodes string with standard base64.")},
+		"unbase64":  {stdUnbase64, stringDoc("unbase64(string)", "Decodes standard base64 string.")},
+		"sha256":    {stringFactory("sha256", sha256Hex), stringDoc("sha256(string)", "Returns hex-encoded SHA-256 hash of string.")},
+		"urlencode": {stringFactory("urlencode", url.QueryEscape), stringDoc("urlencode(string)", "Escapes string for URL query.")},
+		"jsonEncode": {stdJSONEncode, Doc{
+			Signature:   "jsonEncode(value)",
+			Description: "Returns JSON string of value.",
+		}},
+		"jsonDecode": {stdJSONDecode, stringDoc("jsonDecode(string)", "Decodes JSON string to value.")},
+		"len": {stdLen, Doc{
+			Signature:   "len(value)",
+			Description: "Returns length of string, array or object.",
+		}},
+		"string": {stringFactory("string", func(s string) string { return s }), Doc{
+			Signature:   "string(value)",
+			Description: "Converts value to string, non-string values are JSON encoded.",
+			Examples:    []string{`$id | string`},
+		}},
+		"jsonpath": {stdJSONPath, Doc{
+			Signature:   "jsonpath(value, path)",
+			Description: "Returns value at JSON path, JSON strings are decoded.",
+			Args: []ArgDoc{
+				{Name: "value", Description: "JSON value or string with JSON document"},
+				{Name: "path", Description: "JSON path, e.g. \"$.items[0].id\""},
+			},
+			Examples: []string{`$resp | jsonpath("$.items[0].id")`},
+		}},
+	} {
+		s.addStdFactory(name, f.f, f.doc)
+	}
+}
+
+func stringDoc(signature, description string) Doc {
+	return Doc{Signature: signature, Description: description}
+}
+
+func (s *Steps) addStdFactory(name string, f Factory, doc Doc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.factories == nil {
+		s.factories = make(map[string]Factory)
+	}
+
+	if _, ok := s.factories[name]; !ok {
+		s.factories[name] = positionalOnly(name, f)
+		setDoc(&s.factoryDocs, name, []Doc{doc})
+	}
+}
+
+// positionalOnly rejects named arguments of a built-in factory.
+func positionalOnly(name string, f Factory) Factory {
+	return func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		if NewArgs(args...).Named != nil {
+			return ctx, nil, fmt.Errorf("%s does not accept named arguments", name)
+		}
+
+		return f(ctx, args...)
+	}
+}
+
+func stdNow(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	if len(args) != 0 {
+		return ctx, nil, fmt.Errorf("now expects no arguments, %d received", len(args))
+	}
+
+	return ctx, time.Now(), nil
+}
+
+func stdAddDuration(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	if len(args) != 2 {
+		return ctx, nil, fmt.Errorf("addDuration expects 2 arguments: base time, duration, %d received", len(args))
+	}
+
+	base, err := asTime(args[0])
+	if err != nil {
+		return ctx, nil, fmt.Errorf("addDuration base time: %w", err)
+	}
+
+	dur, err := asDuration(args[1])
+	if err != nil {
+		return ctx, nil, fmt.Errorf("addDuration duration: %w", err)
+	}
+
+	return ctx, base.Add(dur), nil
+}
+
+func stdFormatTime(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	if len(args) != 2 {
+		return ctx, nil, fmt.Errorf("formatTime expects 2 arguments: time, layout, %d received", len(args))
+	}
+
+	t, err := asTime(args[0])
+	if err != nil {
+		return ctx, nil, fmt.Errorf("formatTime time: %w", err)
+	}
+
+	layout, ok := args[1].(string)
+	if !ok {
+		return ctx, nil, fmt.Errorf("formatTime layout: unexpected type %T, string expected", args[1])
+	}
+
+	return ctx, t.Format(layout), nil
+}
+
+func stdConcat(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	var sb strings.Builder
+
+	for i, arg := range args {
+		str, err := asString(arg)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("concat argument %d: %w", i, err)
+		}
+
+		sb.WriteString(str)
+	}
+
+	return ctx, sb.String(), nil
+}
+
+// stringFactory creates a factory that transforms single string argument.
+func stringFactory(name string, transform func(s string) string) Factory {
+	return func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		if len(args) != 1 {
+			return ctx, nil, fmt.Errorf("%s expects 1 argument, %d received", name, len(args))
+		}
+
+		str, err := asString(args[0])
+		if err != nil {
+			return ctx, nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		return ctx, transform(str), nil
+	}
+}
+
+func base64Encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func sha256Hex(s string) string {
+	h := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(h[:])
+}
+
+func stdUnbase64(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	if len(args) != 1 {
+		return ctx, nil, fmt.Errorf("unbase64 expects 1 argument, %d received", len(args))
+	}
+
+	str, ok := args[0].(string)
+	if !ok {
+		return ctx, nil, fmt.Errorf("unbase64: unexpected type %T, string expected", args[0])
+	}
+
+	b, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("unbase64: %w", err)
+	}
+
+	return ctx, string(b), nil
+}
+
+func stdJSONEncode(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	if len(args) != 1 {
+		return ctx, nil, fmt.Errorf("jsonEncode expects 1 argument, %d received", len(args))
+	}
+
+	j, err := json.Marshal(args[0])
+	if err != nil {
+		return ctx, nil, fmt.Errorf("jsonEncode: %w", err)
+	}
+
+	return ctx, string(j), nil
+}
+
+func stdJSONDecode(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	if len(args) != 1 {
+		return ctx, nil, fmt.Errorf("jsonDecode expects 1 argument, %d received", len(args))
+	}
+
+	str, ok := args[0].(string)
+	if !ok {
+		return ctx, nil, fmt.Errorf("jsonDecode: unexpected type %T, string expected", args[0])
+	}
+
+	var val interface{}
+	if err := json.Unmarshal([]byte(str), &val); err != nil {
+		return ctx, nil, fmt.Errorf("jsonDecode: %w", err)
+	}
+
+	return ctx, val, nil
+}
+
+func stdLen(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	if len(args) != 1 {
+		return ctx, nil, fmt.Errorf("len expects 1 argument, %d received", len(args))
+	}
+
+	switch v := args[0].(type) {
+	case string:
+		return ctx, utf8.RuneCountInString(v), nil
+	case []interface{}:
+		return ctx, len(v), nil
+	case map[string]interface{}:
+		return ctx, len(v), nil
+	}
+
+	return ctx, nil, fmt.Errorf("len: unexpected type %T, string, array or object expected", args[0])
+}
+
+func stdJSONPath(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+	if len(args) != 2 {
+		return ctx, nil, fmt.Errorf("jsonpath expects 2 arguments: value, path, %d received", len(args))
+	}
+
+	path, ok := args[1].(string)
+	if !ok {
+		return ctx, nil, fmt.Errorf("jsonpath path: unexpected type %T, string expected", args[1])
+	}
+
+	var (
+		val interface{}
+		j   []byte
+		err error
+	)
+
+	// Strings with JSON documents are decoded, other values are normalized to JSON types.
+	if str, ok := args[0].(string); ok {
+		j = []byte(str)
+	} else if j, err = json.Marshal(args[0]); err != nil {
+		return ctx, nil, fmt.Errorf("jsonpath value: %w", err)
+	}
+
+	if err := json.Unmarshal(j, &val); err != nil {
+		return ctx, nil, fmt.Errorf("jsonpath value: %w", err)
+	}
+
+	val, err = jsonpath.Read(val, path)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("jsonpath %s: %w", path, err)
+	}
+
+	return ctx, val, nil
+}
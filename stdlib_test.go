@@ -0,0 +1,27 @@
+package vars
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps_AddStdFactories_namedArgs(t *testing.T) {
+	s := &Steps{}
+	s.AddStdFactories()
+
+	for expr, msg := range map[string]string{
+		`concat("a", sep="-")`: `calling concat at col 1: concat does not accept named arguments`,
+		`upper(x="a")`:         `calling upper at col 1: upper does not accept named arguments`,
+		`string(v=1)`:          `calling string at col 1: string does not accept named arguments`,
+		`len("abc", n=1)`:      `calling len at col 1: len does not accept named arguments`,
+	} {
+		_, _, err := s.calculate(context.Background(), expr)
+		assert.EqualError(t, err, msg, expr)
+	}
+
+	_, val, err := s.calculate(context.Background(), `concat("a", "-", upper("b"))`)
+	assert.NoError(t, err)
+	assert.Equal(t, "a-B", val)
+}
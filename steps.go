@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/bool64/shared"
 	"github.com/cucumber/godog"
 	"github.com/swaggest/assertjson"
+	"github.com/yalp/jsonpath"
 )
 
 // Factory is a function to create variable value.
@@ -20,29 +24,118 @@ type Factory func(ctx context.Context, args ...interface{}) (context.Context, in
 type Steps struct {
 	JSONComparer assertjson.Comparer
 
+	// Seed makes generated random values reproducible, see Rand.
+	//
+	// If zero, seed is taken from GODOG_VARS_SEED env var or current time,
+	// zero value of env var also falls back to current time.
+	// Seed in use is printed on first failed scenario.
+	Seed int64
+
+	// TableConcurrency limits number of independent rows of a variables table that are evaluated in parallel.
+	//
+	// Rows are evaluated one by one if it is less than 2.
+	TableConcurrency int
+
+	// OnceTTL limits lifetime of variables that are set once in a feature, rule, tag or globally.
+	//
+	// Expired variable is not available in following scenarios and is set again by next step that sets it once.
+	// If zero, variables do not expire.
+	OnceTTL time.Duration
+
+	// GlobalCacheFile enables persistent JSON storage of variables set once globally,
+	// so that values are reused by following test runs, e.g. during local development.
+	//
+	// Cached value is set again if its expression changes, if it is older than GlobalCacheTTL or OnceTTL
+	// (when not zero), or if RefreshGlobalCache is true. Values created with a cleanup are not stored.
+	GlobalCacheFile    string
+	GlobalCacheTTL     time.Duration
+	RefreshGlobalCache bool
+
+	// DumpFile receives JSON of global variables after suite, see RegisterSuite.
+	DumpFile string
+
 	mu         sync.Mutex
 	varPrefix  string
-	generators map[string]func() (interface{}, error)
+	generators map[string]GeneratorCtx
 	factories  map[string]Factory
 
+	generatorDocs map[string]Doc
+	factoryDocs   map[string]Doc
+	middlewares   []factoryMiddleware
+
+	rndOnce      sync.Once
+	rnd          *rand.Rand
+	rndUsed      int32
+	seed         int64
+	seedReported sync.Once
+
 	globalVars  map[string]interface{}
 	featureVars map[string]map[string]interface{}
+	ruleVars    map[string]map[string]interface{}
+	tagVars     map[string]map[string]interface{}
+	expiresAt   map[expiryKey]time.Time
+	rules       map[string]map[string]string
+	rulesUsed   map[string]bool
+	formatter   string
+
+	globalCache    map[string]globalCacheEntry
+	cacheRefreshed bool
+
+	sources         []Source
+	sourceErr       error
+	suiteErrs       []error
+	suiteRegistered bool
+
+	globalCleanups  cleanups
+	featureCleanups map[string]*cleanups
+	featureOrder    []string
 }
 
 // AddGenerator registers user-defined generator function, suitable for random identifiers.
-func (s *Steps) AddGenerator(name string, f func() (interface{}, error)) {
+//
+// Optional Doc describes generator in Catalog.
+func (s *Steps) AddGenerator(name string, f func() (interface{}, error), doc ...Doc) {
+	s.AddParamGenerator(name, func(args ...interface{}) (interface{}, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("generator %s does not accept arguments", name)
+		}
+
+		return f()
+	}, doc...)
+}
+
+// AddParamGenerator registers user-defined generator function that accepts arguments, e.g. gen:alphanum(7).
+//
+// Optional Doc describes generator in Catalog.
+func (s *Steps) AddParamGenerator(name string, f func(args ...interface{}) (interface{}, error), doc ...Doc) {
+	s.AddGeneratorCtx(name, func(_ context.Context, args ...interface{}) (interface{}, error) {
+		return f(args...)
+	}, doc...)
+}
+
+// GeneratorCtx is a function to generate value with access to scenario context, see ScenarioFromContext.
+type GeneratorCtx func(ctx context.Context, args ...interface{}) (interface{}, error)
+
+// AddGeneratorCtx registers user-defined generator function that receives scenario context and arguments,
+// e.g. gen:scenarioScopedName("user").
+//
+// Optional Doc describes generator in Catalog.
+func (s *Steps) AddGeneratorCtx(name string, f GeneratorCtx, doc ...Doc) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.generators == nil {
-		s.generators = make(map[string]func() (interface{}, error))
+		s.generators = make(map[string]GeneratorCtx)
 	}
 
 	s.generators[name] = f
+	setDoc(&s.generatorDocs, name, doc)
 }
 
 // AddFactory registers user-defined factory function, suitable for resource creation.
-func (s *Steps) AddFactory(name string, f Factory) {
+//
+// Optional Doc describes factory in Catalog.
+func (s *Steps) AddFactory(name string, f Factory, doc ...Doc) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -51,9 +144,115 @@ func (s *Steps) AddFactory(name string, f Factory) {
 	}
 
 	s.factories[name] = f
+	setDoc(&s.factoryDocs, name, doc)
+}
+
+type (
+	fvCtxKey       struct{}
+	scenarioCtxKey struct{}
+)
+
+// ScenarioFromContext returns current scenario, it is available in context of steps, factories and generators.
+func ScenarioFromContext(ctx context.Context) (*godog.Scenario, bool) {
+	sc, ok := ctx.Value(scenarioCtxKey{}).(*godog.Scenario)
+
+	return sc, ok
+}
+
+// Scope defines shared storage of variables beyond a scenario.
+type Scope int
+
+// Scopes of shared variables.
+const (
+	// ScopeFeature is a storage of variables set once in a feature.
+	ScopeFeature Scope = iota + 1
+	// ScopeGlobal is a storage of variables set once globally.
+	ScopeGlobal
+	// ScopeRule is a storage of variables set once in a rule of a feature.
+	ScopeRule
+)
+
+// Unset removes variable from the scenario and optionally from shared scopes.
+//
+// Variable name should include prefix, e.g. "$foo".
+// Scenario variables are replaced in returned context, OnSet callbacks are kept.
+func (s *Steps) Unset(ctx context.Context, name string, scopes ...Scope) (context.Context, error) {
+	ctx = s.unsetVar(ctx, name)
+
+	if len(scopes) == 0 {
+		return ctx, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeFeature:
+			fv, ok := ctx.Value(fvCtxKey{}).(map[string]interface{})
+			if !ok {
+				return ctx, errors.New("missing feature vars in context")
+			}
+
+			delete(fv, name)
+		case ScopeGlobal:
+			delete(s.globalVars, name)
+		case ScopeRule:
+			_, rv, err := s.ruleVarsOf(ctx)
+			if err != nil {
+				return ctx, err
+			}
+
+			delete(rv, name)
+		default:
+			return ctx, fmt.Errorf("unexpected scope %d", scope)
+		}
+	}
+
+	return ctx, nil
 }
 
-type fvCtxKey struct{}
+// withoutVars hides vars instance of parent context, so that it can be replaced with a fork.
+type withoutVars struct {
+	context.Context
+}
+
+func (c withoutVars) Value(key interface{}) interface{} {
+	val := c.Context.Value(key)
+	if _, ok := val.(*shared.Vars); ok {
+		return nil
+	}
+
+	return val
+}
+
+// unsetVar replaces vars in context with a copy without the variable.
+//
+// Copy is forked from current vars, so that OnSet callbacks are kept,
+// and its storage is changed before the copy is shared.
+func (s *Steps) unsetVar(ctx context.Context, name string) context.Context {
+	ctx, v := s.Vars(ctx)
+	vals := v.GetAll()
+
+	ctx, _ = v.Fork(withoutVars{Context: ctx})
+	m := shared.VarsFromContext(ctx)
+
+	for k := range m {
+		if _, found := vals[k]; !found {
+			delete(m, k)
+		}
+	}
+
+	for k, val := range vals {
+		if k != name {
+			m[k] = val
+		}
+	}
+
+	delete(m, name)
+
+	return ctx
+}
 
 // Register add steps to scenario context.
 func (s *Steps) Register(sc *godog.ScenarioContext) {
@@ -70,10 +269,24 @@ func (s *Steps) Register(sc *godog.ScenarioContext) {
 	}
 
 	sc.Before(s.setupGlobals)
+	sc.After(s.runScenarioCleanups)
+	sc.After(s.reportSeed)
 
 	// Given variable $foo is undefined
 	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is undefined$`, s.varIsUndefined)
 
+	// When variable $foo is unset
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is unset$`, s.varIsUnset)
+
+	// When variable $foo is unset in this feature
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is unset in this feature$`, s.varIsUnsetInThisFeature)
+
+	// When variable $foo is unset in this rule
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is unset in this rule$`, s.varIsUnsetInThisRule)
+
+	// When variable $foo is unset globally
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is unset globally$`, s.varIsUnsetGlobally)
+
 	// When variable $foo is set to "abcdef"
 	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is set to (.+)$`, s.varIsSet)
 
@@ -83,15 +296,54 @@ func (s *Steps) Register(sc *godog.ScenarioContext) {
 	// """
 	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is set to$`, s.varIsSet)
 
+	// When variable $userId is set from $resp at JSON path $.user.id
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is set from \`+s.varPrefix+`([\w\d]+) at JSON path (.+)$`, s.varIsSetFromJSONPath)
+
+	//    When variables are set from $resp at JSON paths
+	//      | $userId   | $.user.id   |
+	//      | $userName | $.user.name |
+	sc.Step(`^variables are set from \`+s.varPrefix+`([\w\d]+) at JSON paths$`, s.varsAreSetFromJSONPaths)
+
+	// When variable $total is calculated as $price * $qty + 1
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) is calculated as (.+)$`, s.varIsCalculated)
+
+	// Then variable $foo equals to [1,2,3] in any order
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) equals to (.+) in any order$`, s.varEqualsInAnyOrder)
+
 	// Then variable $foo equals to "abcdef"
 	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) equals to (.+)$`, s.varEquals)
 
+	// Then variable $foo does not equal to "abcdef"
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) does not equal to (.+)$`, s.varDoesNotEqual)
+
+	// Then variable $list has length 3
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) has length (\d+)$`, s.varHasLength)
+
+	//    Then variable $list contains all of
+	//      | "abc"    |
+	//      | {"id":1} |
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) contains all of$`, s.varContainsAllOf)
+
+	// Then variable $list contains {"id":1}
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) contains (.+)$`, s.varContains)
+
+	//    Then variable $obj has keys
+	//      | id   |
+	//      | name |
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) has keys$`, s.varHasKeys)
+
+	// Then variable $foo matches regexp "^order-(?P<orderId>\d+)$"
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) matches regexp "(.*)"$`, s.varMatchesRegexp)
+
+	// Then variable $foo does not match regexp "^\d+$"
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) does not match regexp "(.*)"$`, s.varDoesNotMatchRegexp)
+
 	//    When variables are set to values
 	//      | $bar   | "abc"             |
 	//      | $baz   | {"one":1,"two":2} |
 	//      | $qux   | 123               |
 	//      | $quux  | true              |
-	//      | $corge | gen:alphanum-7    |
+	//      | $corge | gen:alphanum(7)   |
 	sc.Step(`^variables are set to values$`, s.varsAreSet)
 
 	//    Then variables are equal to values
@@ -106,17 +358,52 @@ func (s *Steps) Register(sc *godog.ScenarioContext) {
 	//      | $baz   | {"one":1,"two":2} |
 	sc.Step(`^variables are set to values once in this feature$`, s.varsAreSetOnceInThisFeature)
 
+	//    When variables are set to values once in this rule
+	//      | $bar   | "abc"             |
+	//      | $baz   | {"one":1,"two":2} |
+	sc.Step(`^variables are set to values once in this rule$`, s.varsAreSetOnceInThisRule)
+
+	// When feature variables are reset
+	sc.Step(`^feature variables are reset$`, s.featureVarsAreReset)
+
 	//    When variables are set to values if undefined globally
 	//      | $bar   | "abc"             |
 	//      | $baz   | {"one":1,"two":2} |
 	sc.Step(`^variables are set to values once globally$`, s.varsAreSetOnceGlobally)
 
+	//    When variables are set to values once for tag @billing
+	//      | $bar   | "abc"             |
+	//      | $baz   | {"one":1,"two":2} |
+	sc.Step(`^variables are set to values once for tag (@\S+)$`, s.varsAreSetOnceForTag)
+
 	//    Then variable $bar matches JSON paths
 	//      | $.foo          | "abcdef"   |
 	//      | $.bar          | 123        |
 	//      | $.baz          | true       |
 	//      | $.prefixed_foo | "ooo::$foo" |
 	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) matches JSON paths$`, s.varMatchesJSONPaths)
+
+	//    Then variable $bar does not match JSON paths
+	//      | $.foo | "abcdef" |
+	//      | $.bar | 123      |
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) does not match JSON paths$`, s.varDoesNotMatchJSONPaths)
+
+	// Then variable $bar does not have JSON path $.foo
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) does not have JSON path (.+)$`, s.varDoesNotHaveJSONPath)
+
+	//    Then variable $bar does not have JSON paths
+	//      | $.foo |
+	//      | $.bar |
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) does not have JSON paths$`, s.varDoesNotHaveJSONPaths)
+
+	//    Then variable $bar matches JSON schema
+	//    """json5
+	//    {"type":"object","required":["foo"]}
+	//    """
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) matches JSON schema$`, s.varMatchesJSONSchema)
+
+	// Then variable $bar matches JSON schema from file "schemas/user.json"
+	sc.Step(`^variable \`+s.varPrefix+`([\w\d]+) matches JSON schema from file "(.+)"$`, s.varMatchesJSONSchemaFromFile)
 }
 
 func (s *Steps) setupGlobals(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
@@ -133,9 +420,28 @@ func (s *Steps) setupGlobals(ctx context.Context, sc *godog.Scenario) (context.C
 		s.featureVars[sc.Uri] = fv
 	}
 
+	s.expire(featureScope(sc.Uri), fv)
+	s.expire(globalScope, s.globalVars)
+
+	for _, tag := range sc.Tags {
+		s.expire(tagScope(tag.Name), s.tagVars[tag.Name])
+	}
+
 	ctx = context.WithValue(ctx, fvCtxKey{}, fv)
+	ctx = context.WithValue(ctx, scenarioCtxKey{}, sc)
+	ctx = s.startScenarioCleanups(ctx, sc)
+
+	// Scenario fails after cleanup scope is started, as cleanups run after failed scenario too.
+	if s.sourceErr != nil {
+		return ctx, s.sourceErr
+	}
 
-	if len(fv) == 0 && len(s.globalVars) == 0 {
+	ctx, rv, err := s.setupRuleVars(ctx, sc)
+	if err != nil {
+		return ctx, err
+	}
+
+	if len(fv) == 0 && len(rv) == 0 && len(s.globalVars) == 0 && len(s.tagVars) == 0 {
 		return ctx, nil
 	}
 
@@ -145,10 +451,20 @@ func (s *Steps) setupGlobals(ctx context.Context, sc *godog.Scenario) (context.C
 		v.Set(key, val)
 	}
 
+	for _, tag := range sc.Tags {
+		for key, val := range s.tagVars[tag.Name] {
+			v.Set(key, val)
+		}
+	}
+
 	for key, val := range fv {
 		v.Set(key, val)
 	}
 
+	for key, val := range rv {
+		v.Set(key, val)
+	}
+
 	return ctx, nil
 }
 
@@ -168,6 +484,22 @@ func (s *Steps) varIsUndefined(ctx context.Context, name string) error {
 	return nil
 }
 
+func (s *Steps) varIsUnset(ctx context.Context, name string) (context.Context, error) {
+	return s.Unset(ctx, s.varPrefix+name)
+}
+
+func (s *Steps) varIsUnsetInThisFeature(ctx context.Context, name string) (context.Context, error) {
+	return s.Unset(ctx, s.varPrefix+name, ScopeFeature)
+}
+
+func (s *Steps) varIsUnsetInThisRule(ctx context.Context, name string) (context.Context, error) {
+	return s.Unset(ctx, s.varPrefix+name, ScopeRule)
+}
+
+func (s *Steps) varIsUnsetGlobally(ctx context.Context, name string) (context.Context, error) {
+	return s.Unset(ctx, s.varPrefix+name, ScopeGlobal)
+}
+
 func (s *Steps) varIsSet(ctx context.Context, name, value string) (context.Context, error) {
 	ctx, v := s.JSONComparer.Vars.Fork(ctx)
 
@@ -181,116 +513,109 @@ func (s *Steps) varIsSet(ctx context.Context, name, value string) (context.Conte
 	return ctx, nil
 }
 
-var commaInBrackets = regexp.MustCompile(`\(.+(,+?).+\)`)
-
-func (s *Steps) value(ctx context.Context, value string) (context.Context, interface{}, error) {
-	ctx, rv, err := s.Replace(ctx, []byte(value))
+// jsonPathValue reads value of a variable at JSON path.
+func (s *Steps) jsonPathValue(ctx context.Context, name, path string) (interface{}, error) {
+	val, err := s.jsonDecodedValue(ctx, name)
 	if err != nil {
-		return ctx, nil, fmt.Errorf("replacing vars in %s: %w", value, err)
+		return nil, err
 	}
 
-	ctx, val, err := s.factory(ctx, value)
-	if err == nil {
-		return ctx, val, nil
+	val, err = jsonpath.Read(val, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON path %s in variable %s: %w", path, name, err)
 	}
 
-	if !errors.Is(err, errSkipped) {
-		return ctx, nil, err
-	}
+	return val, nil
+}
 
-	val, err = s.gen(value)
-	if err == nil {
-		return ctx, val, nil
-	}
+func (s *Steps) varIsSetFromJSONPath(ctx context.Context, name, source, path string) (context.Context, error) {
+	ctx, v := s.Vars(ctx)
 
-	if !errors.Is(err, errSkipped) {
-		return ctx, nil, err
+	val, err := s.jsonPathValue(ctx, source, path)
+	if err != nil {
+		return ctx, err
 	}
 
-	if err := json.Unmarshal(rv, &val); err != nil {
-		return ctx, nil, fmt.Errorf("decoding variable with value %s as JSON: %w", value, err)
-	}
+	v.Set(s.varPrefix+name, val)
 
-	return ctx, val, nil
+	return ctx, nil
 }
 
-type sentinelError string
-
-func (s sentinelError) Error() string {
-	return string(s)
-}
+func (s *Steps) varsAreSetFromJSONPaths(ctx context.Context, source string, table *godog.Table) (context.Context, error) {
+	ctx, v := s.Vars(ctx)
 
-const errSkipped = sentinelError("skipped")
+	for _, row := range table.Rows {
+		if len(row.Cells) != 2 {
+			return ctx, fmt.Errorf("two columns expected in the table, %d received", len(row.Cells))
+		}
 
-func (s *Steps) factory(ctx context.Context, value string) (context.Context, interface{}, error) {
-	p := strings.Index(value, "(")
-	if p < 1 {
-		return ctx, nil, errSkipped
-	}
+		name := row.Cells[0].Value
+		path := row.Cells[1].Value
 
-	fn := value[0:p]
-	fn = strings.TrimSpace(fn)
-	f, ok := s.factories[fn]
+		val, err := s.jsonPathValue(ctx, source, path)
+		if err != nil {
+			return ctx, fmt.Errorf("%s: %w", name, err)
+		}
 
-	if !ok {
-		return ctx, nil, errSkipped
+		v.Set(name, val)
 	}
 
-	if value[len(value)-1] != ')' {
-		return ctx, nil, errors.New("missing closing parenthesis ')' in factory expression")
-	}
+	return ctx, nil
+}
 
-	value = value[p+1 : len(value)-1]
+func (s *Steps) varIsCalculated(ctx context.Context, name, expr string) (context.Context, error) {
+	ctx, v := s.JSONComparer.Vars.Fork(ctx)
 
-	if len(strings.TrimSpace(value)) == 0 {
-		return f(ctx)
+	ctx, val, err := s.calculate(ctx, expr)
+	if err != nil {
+		return ctx, fmt.Errorf("%s: %w", name, err)
 	}
 
-	v := strings.ReplaceAll(value, `\\,`, `\&slashcomma;`)
-	v = strings.ReplaceAll(v, `\,`, `\&comma;`)
-	v = commaInBrackets.ReplaceAllStringFunc(v, func(s string) string {
-		return strings.ReplaceAll(s, `,`, `\&comma;`)
-	})
+	v.Set(s.varPrefix+name, val)
 
-	var (
-		args  = strings.Split(v, ",")
-		vargs = make([]interface{}, len(args))
-		varg  interface{}
-		err   error
-	)
+	return ctx, nil
+}
 
-	ctx = s.PrepareContext(ctx)
+func (s *Steps) value(ctx context.Context, value string) (context.Context, interface{}, error) {
+	ctx, rv, err := s.replaceVars(ctx, []byte(value))
+	if err != nil {
+		return ctx, nil, fmt.Errorf("replacing vars in %s: %w", value, err)
+	}
 
-	for i, arg := range args {
-		arg = strings.ReplaceAll(arg, `\&comma;`, `,`)
-		arg = strings.ReplaceAll(arg, `\&slashcomma;`, `\,`)
+	if json.Valid(rv) {
+		// Templates are only evaluated for JSON values, expressions evaluate them in string literals.
+		if strings.Contains(value, "${") {
+			if ctx, rv, err = s.Replace(ctx, []byte(value)); err != nil {
+				return ctx, nil, fmt.Errorf("replacing vars in %s: %w", value, err)
+			}
+		}
 
-		_, varg, err = s.value(ctx, arg)
-		if err != nil {
-			return ctx, nil, fmt.Errorf("parse factory argument %d %q: %w", i, arg, err)
+		var val interface{}
+		if err := json.Unmarshal(rv, &val); err != nil {
+			return ctx, nil, fmt.Errorf("decoding variable with value %s as JSON: %w", value, err)
 		}
 
-		vargs[i] = varg
+		return ctx, val, nil
 	}
 
-	return f(ctx, vargs...)
-}
-
-func (s *Steps) gen(value string) (interface{}, error) {
-	if !strings.HasPrefix(value, "gen:") {
-		return nil, errSkipped
+	// Values that are not JSON are expressions, e.g. newUserID("$foo", addDuration(now(), "-10h")) or $price * $qty.
+	n, err := s.parseExpr(value)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("parsing value %s: %w", value, err)
 	}
 
-	gen := value[4:]
+	return s.evalExpr(s.PrepareContext(ctx), n)
+}
 
-	f, ok := s.generators[gen]
+func (s *Steps) generate(ctx context.Context, name string, args []interface{}) (interface{}, error) {
+	f, ok := s.generators[name]
 	if !ok {
-		return nil, fmt.Errorf("missing generator %q", gen)
+		return nil, fmt.Errorf("missing generator %q", name)
 	}
 
-	val, err := f()
+	val, err := f(ctx, args...)
 	if err != nil {
-		return nil, fmt.Errorf("generating value with %q: %w", gen, err)
+		return nil, fmt.Errorf("generating value with %q: %w", name, err)
 	}
 
 	return val, nil
@@ -316,34 +641,110 @@ func (s *Steps) varEquals(ctx context.Context, name, value string) error {
 	return nil
 }
 
-func (s *Steps) walkVars(ctx context.Context, table *godog.Table, override map[string]interface{}, cb func(name string, val interface{})) error {
-	for _, row := range table.Rows {
-		if len(row.Cells) != 2 {
-			return fmt.Errorf("two columns expected in the table, %d received", len(row.Cells))
-		}
+// assertNotEqual fails if JSON value with vars interpolated is equal to actual value.
+func (s *Steps) assertNotEqual(ctx context.Context, value string, actual interface{}) error {
+	_, rv, err := s.Replace(ctx, []byte(value))
+	if err != nil {
+		return fmt.Errorf("replacing vars in %s: %w", value, err)
+	}
 
-		name := row.Cells[0].Value
-		value := row.Cells[1].Value
+	if !json.Valid(rv) {
+		return fmt.Errorf("invalid JSON value %s", value)
+	}
+
+	if err := assertjson.FailNotEqualMarshal(rv, actual); err == nil {
+		return fmt.Errorf("unexpected value %s", string(rv))
+	}
+
+	return nil
+}
+
+func (s *Steps) varDoesNotEqual(ctx context.Context, name, value string) error {
+	_, v := s.Vars(ctx)
+
+	stored, found := v.Get(s.varPrefix + name)
+	if !found {
+		return fmt.Errorf("could not find variable %s", name)
+	}
+
+	if err := s.assertNotEqual(ctx, value, stored); err != nil {
+		return fmt.Errorf("variable %s assertion failed: %w", name, err)
+	}
+
+	return nil
+}
+
+// stringValue returns string value of a variable, non-string values are encoded as JSON.
+func (s *Steps) stringValue(ctx context.Context, name string) (string, error) {
+	_, v := s.Vars(ctx)
+
+	stored, found := v.Get(s.varPrefix + name)
+	if !found {
+		return "", fmt.Errorf("could not find variable %s", name)
+	}
+
+	if str, ok := stored.(string); ok {
+		return str, nil
+	}
+
+	j, err := json.Marshal(stored)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal variable %s: %w", name, err)
+	}
+
+	return string(j), nil
+}
 
-		if v, found := override[name]; found {
-			cb(name, v)
+func (s *Steps) varMatchesRegexp(ctx context.Context, name, pattern string) (context.Context, error) {
+	ctx, v := s.Vars(ctx)
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ctx, fmt.Errorf("invalid regexp %q: %w", pattern, err)
+	}
 
+	str, err := s.stringValue(ctx, name)
+	if err != nil {
+		return ctx, err
+	}
+
+	m := re.FindStringSubmatchIndex(str)
+	if m == nil {
+		return ctx, fmt.Errorf("variable %s with value %q does not match regexp %q", name, str, pattern)
+	}
+
+	// Named groups are captured into variables.
+	for i, group := range re.SubexpNames() {
+		if group == "" || m[2*i] < 0 {
 			continue
 		}
 
-		_, val, err := s.value(ctx, value)
-		if err != nil {
-			return fmt.Errorf("%s: %w", name, err)
-		}
+		v.Set(s.varPrefix+group, str[m[2*i]:m[2*i+1]])
+	}
+
+	return ctx, nil
+}
+
+func (s *Steps) varDoesNotMatchRegexp(ctx context.Context, name, pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regexp %q: %w", pattern, err)
+	}
+
+	str, err := s.stringValue(ctx, name)
+	if err != nil {
+		return err
+	}
 
-		cb(name, val)
+	if re.MatchString(str) {
+		return fmt.Errorf("variable %s with value %q matches regexp %q", name, str, pattern)
 	}
 
 	return nil
 }
 
 func (s *Steps) varsAreSetOnceInThisFeature(ctx context.Context, table *godog.Table) (context.Context, error) {
-	ctx, v := s.Vars(ctx)
+	ctx, _ = s.Vars(ctx)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -353,26 +754,102 @@ func (s *Steps) varsAreSetOnceInThisFeature(ctx context.Context, table *godog.Ta
 		return ctx, errors.New("BUG: missing feature vars in context")
 	}
 
-	err := s.walkVars(ctx, table, fv, func(name string, val interface{}) {
-		fv[name] = val
-		v.Set(name, val)
-	})
+	sc, ok := ScenarioFromContext(ctx)
+	if !ok {
+		return ctx, errors.New("BUG: missing scenario in context")
+	}
 
-	return ctx, err
+	return ctx, s.setOnce(ctx, featureScope(sc.Uri), fv, ctx.Value(featureCleanupsCtxKey{}), table)
 }
 
 func (s *Steps) varsAreSetOnceGlobally(ctx context.Context, table *godog.Table) (context.Context, error) {
-	ctx, v := s.Vars(ctx)
+	ctx, _ = s.Vars(ctx)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	err := s.walkVars(ctx, table, s.globalVars, func(name string, val interface{}) {
-		s.globalVars[name] = val
+	if s.GlobalCacheFile == "" {
+		return ctx, s.setOnce(ctx, globalScope, s.globalVars, &s.globalCleanups, table)
+	}
+
+	missing, err := s.useGlobalCache(table)
+	if err != nil {
+		return ctx, err
+	}
+
+	err = s.setOnce(ctx, globalScope, s.globalVars, &s.globalCleanups, table)
+
+	// Values that were evaluated before an error are stored too.
+	if cerr := s.storeGlobalCache(table, missing); err == nil {
+		err = cerr
+	}
+
+	return ctx, err
+}
+
+// setOnce sets variables of a shared scope, variables that are already set in the scope are reused.
+//
+// Cleanups of created resources are added to cleanupScope.
+// Must be called with s.mu locked and with context instrumented with vars.
+func (s *Steps) setOnce(ctx context.Context, scope string, vals map[string]interface{}, cleanupScope interface{}, table *godog.Table) error {
+	_, v := s.Vars(ctx)
+
+	s.expire(scope, vals)
+
+	cc := context.WithValue(ctx, cleanupsCtxKey{}, cleanupScope)
+
+	// Cleanups of shared scopes only run after suite with RegisterSuite hooks.
+	if !s.suiteRegistered {
+		cc = context.WithValue(cc, unmanagedScopeCtxKey{}, scope)
+	}
+
+	return s.walkVars(cc, table, vals, func(name string, val interface{}) {
+		if _, found := vals[name]; !found {
+			s.setExpiry(scope, name, time.Now())
+		}
+
+		vals[name] = val
 		v.Set(name, val)
 	})
+}
 
-	return ctx, err
+func (s *Steps) varsAreSetOnceForTag(ctx context.Context, tag string, table *godog.Table) (context.Context, error) {
+	ctx, _ = s.Vars(ctx)
+
+	sc, ok := ScenarioFromContext(ctx)
+	if !ok {
+		return ctx, errors.New("BUG: missing scenario in context")
+	}
+
+	tagged := false
+
+	for _, t := range sc.Tags {
+		if t.Name == tag {
+			tagged = true
+
+			break
+		}
+	}
+
+	if !tagged {
+		return ctx, fmt.Errorf("scenario %q is not tagged with %s", sc.Name, tag)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tagVars == nil {
+		s.tagVars = make(map[string]map[string]interface{})
+	}
+
+	tv := s.tagVars[tag]
+	if tv == nil {
+		tv = make(map[string]interface{})
+		s.tagVars[tag] = tv
+	}
+
+	// Tagged scenarios can span multiple features, so resources are cleaned up after suite.
+	return ctx, s.setOnce(ctx, tagScope(tag), tv, &s.globalCleanups, table)
 }
 
 func (s *Steps) varsAreSet(ctx context.Context, table *godog.Table) (context.Context, error) {
@@ -414,18 +891,149 @@ func (s *Steps) varsAreEqual(ctx context.Context, table *godog.Table) error {
 	return nil
 }
 
-func (s *Steps) varMatchesJSONPaths(ctx context.Context, name string, jsonPaths *godog.Table) (context.Context, error) {
-	ctx, v := s.Vars(ctx)
+// jsonValue returns value of a variable encoded as JSON.
+func (s *Steps) jsonValue(ctx context.Context, name string) ([]byte, error) {
+	_, v := s.Vars(ctx)
 
 	stored, found := v.Get(s.varPrefix + name)
 	if !found {
-		return ctx, fmt.Errorf("could not find variable %s", name)
+		return nil, fmt.Errorf("could not find variable %s", name)
 	}
 
 	j, err := json.Marshal(stored)
 	if err != nil {
-		return ctx, fmt.Errorf("failed to marshal variable %s: %w", name, err)
+		return nil, fmt.Errorf("failed to marshal variable %s: %w", name, err)
+	}
+
+	return j, nil
+}
+
+// jsonDecodedValue returns value of a variable decoded from JSON, so that it only contains JSON types.
+func (s *Steps) jsonDecodedValue(ctx context.Context, name string) (interface{}, error) {
+	j, err := s.jsonValue(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var val interface{}
+	if err := json.Unmarshal(j, &val); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal variable %s: %w", name, err)
+	}
+
+	return val, nil
+}
+
+func (s *Steps) varMatchesJSONPaths(ctx context.Context, name string, jsonPaths *godog.Table) (context.Context, error) {
+	ctx, _ = s.Vars(ctx)
+
+	j, err := s.jsonValue(ctx, name)
+	if err != nil {
+		return ctx, err
 	}
 
 	return s.AssertJSONPaths(ctx, jsonPaths, j, true)
 }
+
+func (s *Steps) varDoesNotMatchJSONPaths(ctx context.Context, name string, jsonPaths *godog.Table) error {
+	for _, row := range jsonPaths.Rows {
+		if len(row.Cells) != 2 {
+			return fmt.Errorf("two columns expected in the table, %d received", len(row.Cells))
+		}
+
+		path := row.Cells[0].Value
+
+		val, err := s.jsonPathValue(ctx, name, path)
+		if err != nil {
+			return err
+		}
+
+		if err := s.assertNotEqual(ctx, row.Cells[1].Value, val); err != nil {
+			return fmt.Errorf("variable %s assertion failed at JSON path %s: %w", name, path, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Steps) varDoesNotHaveJSONPath(ctx context.Context, name, path string) error {
+	val, err := s.jsonDecodedValue(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	read, err := jsonpath.Prepare(path)
+	if err != nil {
+		return fmt.Errorf("invalid JSON path %s: %w", path, err)
+	}
+
+	// Path is valid, so errors of reading mean that it does not exist in the value.
+	found, err := read(val)
+	if err != nil || isEmptySearch(path, found) {
+		return nil
+	}
+
+	fj, err := json.Marshal(found)
+	if err != nil {
+		return fmt.Errorf("variable %s has JSON path %s", name, path)
+	}
+
+	return fmt.Errorf("variable %s has JSON path %s with value %s", name, path, string(fj))
+}
+
+// isEmptySearch checks if value is an empty result of a path that can match multiple values,
+// e.g. deep scan $..id or wildcard $.items[*].id, unlike an empty array at a single value path.
+func isEmptySearch(path string, found interface{}) bool {
+	if !strings.Contains(path, "..") && !strings.ContainsAny(path, "*:,") {
+		return false
+	}
+
+	res, ok := found.([]interface{})
+
+	return ok && len(res) == 0
+}
+
+func (s *Steps) varDoesNotHaveJSONPaths(ctx context.Context, name string, jsonPaths *godog.Table) error {
+	for _, row := range jsonPaths.Rows {
+		if len(row.Cells) != 1 {
+			return fmt.Errorf("one column expected in the table, %d received", len(row.Cells))
+		}
+
+		if err := s.varDoesNotHaveJSONPath(ctx, name, row.Cells[0].Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Steps) varMatchesJSONSchema(ctx context.Context, name, schema string) (context.Context, error) {
+	ctx, _ = s.Vars(ctx)
+
+	j, err := s.jsonValue(ctx, name)
+	if err != nil {
+		return ctx, err
+	}
+
+	ctx, err = s.AssertJSONSchema(ctx, []byte(schema), j)
+	if err != nil {
+		return ctx, fmt.Errorf("variable %s: %w", name, err)
+	}
+
+	return ctx, nil
+}
+
+func (s *Steps) varMatchesJSONSchemaFromFile(ctx context.Context, name, filePath string) (context.Context, error) {
+	ctx, _ = s.Vars(ctx)
+
+	j, err := s.jsonValue(ctx, name)
+	if err != nil {
+		return ctx, err
+	}
+
+	ctx, err = s.AssertJSONSchemaFile(ctx, filePath, j)
+	if err != nil {
+		return ctx, fmt.Errorf("variable %s: %w", name, err)
+	}
+
+	return ctx, nil
+}
@@ -1,9 +1,11 @@
 package vars_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/cucumber/godog"
 	"github.com/godogx/vars"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFeatures(t *testing.T) {
@@ -19,6 +22,20 @@ func TestFeatures(t *testing.T) {
 		return 1337, nil
 	})
 
+	ruleSeq := 0
+	vs.AddGenerator("ruleSeq", func() (interface{}, error) {
+		ruleSeq++
+
+		return ruleSeq, nil
+	})
+
+	resetSeq := 0
+	vs.AddGenerator("resetSeq", func() (interface{}, error) {
+		resetSeq++
+
+		return resetSeq, nil
+	})
+
 	vs.AddFactory("now", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
 		// "Now" is mocked with a constant value to reproducibility.
 		return ctx, time.Date(2023, 5, 22, 19, 38, 0, 0, time.UTC), nil
@@ -125,9 +142,92 @@ func TestFeatures(t *testing.T) {
 	}
 
 	suite.Options = &godog.Options{
-		Format:   "pretty",
-		Strict:   true,
-		Paths:    []string{"_testdata/Vars.feature"},
+		Format: "pretty",
+		Strict: true,
+		Paths: []string{
+			"_testdata/Vars.feature",
+			"_testdata/Calc.feature",
+			"_testdata/Regexp.feature",
+			"_testdata/JSONSchema.feature",
+			"_testdata/Unset.feature",
+			"_testdata/JSONPath.feature",
+			"_testdata/Negative.feature",
+			"_testdata/Collection.feature",
+			"_testdata/Rules.feature",
+			"_testdata/Reset.feature",
+		},
+		TestingT: t,
+	}
+
+	vs.RegisterFormatter(suite.Options)
+
+	assert.Zero(t, suite.Run(), "suite failed")
+}
+
+func TestFeatures_stdlib(t *testing.T) {
+	vs := vars.Steps{}
+	vs.AddGenerator("new-id", func() (interface{}, error) {
+		return 1337, nil
+	})
+
+	vs.AddParamGenerator("prefixed", func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.New("prefixed expects 1 argument: prefix")
+		}
+
+		return fmt.Sprintf("%s-%d", args[0], 12345), nil
+	})
+
+	vs.AddGeneratorCtx("scenarioScopedName", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		sc, ok := vars.ScenarioFromContext(ctx)
+		if !ok {
+			return nil, errors.New("missing scenario in context")
+		}
+
+		return fmt.Sprintf("%s-%s", args[0], strings.ReplaceAll(strings.ToLower(sc.Name), " ", "-")), nil
+	})
+
+	vs.AddStdFactories()
+	vs.AddStdGenerators()
+
+	vs.AddFactory("now", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		// "Now" is mocked with a constant value to reproducibility.
+		return ctx, time.Date(2023, 5, 22, 19, 38, 0, 0, time.UTC), nil
+	})
+
+	vs.AddFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		a := vars.NewArgs(args...)
+
+		name, err := a.String(0, "name")
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		age, err := a.Int(1, "age")
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		role, err := a.String(-1, "role")
+		if err != nil {
+			role = "user"
+		}
+
+		return ctx, map[string]interface{}{"name": name, "age": age, "role": role}, nil
+	})
+
+	suite := godog.TestSuite{}
+	suite.ScenarioInitializer = vs.Register
+
+	suite.Options = &godog.Options{
+		Format: "pretty",
+		Strict: true,
+		Paths: []string{
+			"_testdata/Stdlib.feature",
+			"_testdata/Generators.feature",
+			"_testdata/NamedArgs.feature",
+			"_testdata/Dependencies.feature",
+		},
 		TestingT: t,
 	}
 
@@ -171,3 +271,179 @@ func TestFeatures_global(t *testing.T) {
 
 	assert.Zero(t, suite.Run(), "suite failed")
 }
+
+func TestFeatures_tag(t *testing.T) {
+	var tagSeq int64
+
+	vs := vars.Steps{}
+
+	vs.AddGenerator("tagSeq", func() (interface{}, error) {
+		return atomic.AddInt64(&tagSeq, 1), nil
+	})
+
+	out := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Output: out,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Billing.feature",
+					Contents: []byte(`Feature: Billing
+  @billing
+  Scenario: First billing scenario
+    Given variables are set to values once for tag @billing
+      | $account | gen:tagSeq |
+    Then variable $account equals to 1
+
+  Scenario: Untagged scenario
+    Given variable $account is undefined
+`),
+				},
+				{
+					Name: "Invoices.feature",
+					Contents: []byte(`@billing
+Feature: Invoices
+  Scenario: Second billing scenario
+    Given variable $account equals to 1
+    And variables are set to values once for tag @billing
+      | $account | gen:tagSeq |
+    Then variable $account equals to 1
+
+  @reports
+  Scenario: Scenario with another tag
+    Given variables are set to values once for tag @invoices
+      | $invoice | gen:tagSeq |
+`),
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run())
+	assert.Contains(t, out.String(), `scenario "Scenario with another tag" is not tagged with @invoices`)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&tagSeq))
+}
+
+func TestSteps_Unset(t *testing.T) {
+	vs := vars.Steps{}
+	ctx, v := vs.Vars(context.Background())
+
+	calls := 0
+
+	v.OnSet(func(key string, val interface{}) {
+		calls++
+	})
+
+	v.Set("$a", 1)
+	v.Set("$b", 2)
+
+	ctx, err := vs.Unset(ctx, "$a")
+	require.NoError(t, err)
+
+	// Vars in returned context keep callbacks.
+	_, v = vs.Vars(ctx)
+	v.Set("$c", 3)
+
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, map[string]interface{}{"$b": 2, "$c": 3}, v.GetAll())
+
+	_, found := v.Get("$a")
+	assert.False(t, found)
+}
+
+func TestSteps_Unset_concurrent(t *testing.T) {
+	vs := vars.Steps{}
+	ctx, v := vs.Vars(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			v.Set("$b", i)
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		v.Set("$a", i)
+
+		_, err := vs.Unset(ctx, "$a")
+		require.NoError(t, err)
+	}
+
+	<-done
+}
+
+func TestSteps_varDoesNotHaveJSONPath_errors(t *testing.T) {
+	for path, expected := range map[string]string{
+		"a.b":     "invalid JSON path a.b: path must start with a '$'",
+		"$..":     "invalid JSON path $..: cannot end with a scan '..' at 4",
+		"$..name": `variable user has JSON path $..name with value ["John"]`,
+		"$.tags":  `variable user has JSON path $.tags with value []`,
+	} {
+		t.Run(path, func(t *testing.T) {
+			out := bytes.NewBuffer(nil)
+			vs := vars.Steps{}
+
+			suite := godog.TestSuite{
+				ScenarioInitializer: vs.Register,
+				Options: &godog.Options{
+					Format:   "progress",
+					Output:   out,
+					NoColors: true,
+					FeatureContents: []godog.Feature{{
+						Name: "Negative.feature",
+						Contents: []byte(`Feature: Negative
+  Scenario: Asserting absent JSON path
+    Given variable $user is set to
+    """json
+    {"name": "John", "tags": []}
+    """
+    Then variable $user does not have JSON path ` + path + `
+`),
+					}},
+				},
+			}
+
+			assert.Equal(t, 1, suite.Run())
+			assert.Contains(t, out.String(), expected)
+		})
+	}
+}
+
+func TestFeatures_AddFunc(t *testing.T) {
+	vs := vars.Steps{}
+	vs.AddStdFactories()
+
+	vs.AddFunc("newUserID", func(name string, registeredAt time.Time) int {
+		if name != "John Doe" || !registeredAt.Equal(time.Date(2023, 5, 22, 9, 38, 0, 0, time.UTC)) {
+			return 0
+		}
+
+		return 12321
+	})
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Strict: true,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "AddFunc.feature",
+					Contents: []byte(`Feature: AddFunc
+  Scenario: Typed factory
+    Given variable $name is set to "John Doe"
+    When variable $userId is set to newUserID("$name", addDuration("2023-05-22T19:38:00Z", "-10h"))
+    Then variable $userId equals to 12321
+`),
+				},
+			},
+		},
+	}
+
+	assert.Zero(t, suite.Run())
+}
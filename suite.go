@@ -0,0 +1,131 @@
+package vars
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cucumber/godog"
+	"github.com/swaggest/assertjson/json5"
+)
+
+// Source provides values of variables, e.g. from a file or environment.
+type Source func() (map[string]interface{}, error)
+
+// FileSource reads variables from a JSON or JSON5 file with an object of variable names and values,
+// e.g. {"$baseURL": "http://localhost:8080"}.
+func FileSource(filePath string) Source {
+	return func() (map[string]interface{}, error) {
+		body, err := os.ReadFile(filePath) //nolint // File inclusion via variable during tests.
+		if err != nil {
+			return nil, err
+		}
+
+		if json5.Valid(body) {
+			if body, err = json5.Downgrade(body); err != nil {
+				return nil, fmt.Errorf("failed to downgrade JSON5 to JSON: %w", err)
+			}
+		}
+
+		var vars map[string]interface{}
+		if err := json.Unmarshal(body, &vars); err != nil {
+			return nil, fmt.Errorf("decoding variables from %s: %w", filePath, err)
+		}
+
+		return vars, nil
+	}
+}
+
+// AddSource registers a source of global variables that are loaded before suite, see RegisterSuite.
+//
+// Sources are loaded in order of addition, later sources override values of earlier ones.
+// Loaded variables are available in all scenarios, same as variables set once globally.
+func (s *Steps) AddSource(src Source) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sources = append(s.sources, src)
+}
+
+// RegisterSuite adds suite hooks to load variables from sources before suite,
+// and to dump global variables to DumpFile and to call Cleanup after suite.
+//
+// Failed loading of sources fails every scenario, other errors are available with SuiteError after suite.
+func (s *Steps) RegisterSuite(tc *godog.TestSuiteContext) {
+	s.mu.Lock()
+	s.suiteRegistered = true
+	s.mu.Unlock()
+
+	tc.BeforeSuite(s.loadSources)
+	tc.AfterSuite(s.finishSuite)
+}
+
+// SuiteError returns errors of suite hooks added with RegisterSuite, multiple errors are combined into one.
+func (s *Steps) SuiteError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return joinErrors("", s.suiteErrs)
+}
+
+func (s *Steps) loadSources() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.suiteErrs = nil
+	s.sourceErr = nil
+
+	if s.globalVars == nil {
+		s.globalVars = make(map[string]interface{})
+	}
+
+	for i, src := range s.sources {
+		vars, err := src()
+		if err != nil {
+			s.suiteErrs = append(s.suiteErrs, fmt.Errorf("loading variables from source %d: %w", i+1, err))
+
+			continue
+		}
+
+		for name, val := range vars {
+			s.globalVars[name] = val
+		}
+	}
+
+	s.sourceErr = joinErrors("", s.suiteErrs)
+}
+
+func (s *Steps) finishSuite() {
+	if err := s.dumpGlobals(); err != nil {
+		s.mu.Lock()
+		s.suiteErrs = append(s.suiteErrs, err)
+		s.mu.Unlock()
+	}
+
+	if err := s.Cleanup(); err != nil {
+		s.mu.Lock()
+		s.suiteErrs = append(s.suiteErrs, err)
+		s.mu.Unlock()
+	}
+}
+
+// dumpGlobals writes global variables to DumpFile for debugging.
+func (s *Steps) dumpGlobals() error {
+	if s.DumpFile == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.globalVars, "", " ")
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("encoding global variables: %w", err)
+	}
+
+	if err := os.WriteFile(s.DumpFile, data, 0o600); err != nil {
+		return fmt.Errorf("dumping global variables: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package vars_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSteps_RegisterSuite(t *testing.T) {
+	dir := t.TempDir()
+	sourceFile := filepath.Join(dir, "vars.json5")
+
+	require.NoError(t, os.WriteFile(sourceFile, []byte(`{
+  // Local environment.
+  "$baseURL": "http://localhost:8080",
+  "$account": 42,
+}`), 0o600))
+
+	cleaned := false
+
+	vs := &vars.Steps{DumpFile: filepath.Join(dir, "dump.json")}
+	vs.AddSource(vars.FileSource(sourceFile))
+	vs.AddSource(func() (map[string]interface{}, error) {
+		return map[string]interface{}{"$account": 43}, nil
+	})
+	vs.AddCleanupFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, func(ctx context.Context) error, error) {
+		return ctx, "user", func(ctx context.Context) error {
+			cleaned = true
+
+			return nil
+		}, nil
+	})
+
+	suite := godog.TestSuite{
+		TestSuiteInitializer: vs.RegisterSuite,
+		ScenarioInitializer:  vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Strict: true,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Suite.feature",
+					Contents: []byte(`Feature: Suite
+  Scenario: Preloaded variables
+    Given variables are set to values once globally
+      | $account | 1                |
+      | $user    | newUser()        |
+      | $url     | "$baseURL/users" |
+    Then variables are equal to values
+      | $baseURL | "http://localhost:8080"       |
+      | $account | 43                            |
+      | $url     | "http://localhost:8080/users" |
+`),
+				},
+			},
+		},
+	}
+
+	assert.Zero(t, suite.Run())
+	assert.NoError(t, vs.SuiteError())
+	assert.True(t, cleaned)
+
+	dump, err := os.ReadFile(filepath.Join(dir, "dump.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$account": 43,
+		"$baseURL": "http://localhost:8080",
+		"$url": "http://localhost:8080/users",
+		"$user": "user"
+	}`, string(dump))
+}
+
+func TestSteps_RegisterSuite_sourceError(t *testing.T) {
+	vs := &vars.Steps{}
+	vs.AddSource(vars.FileSource(filepath.Join(t.TempDir(), "missing.json")))
+	vs.AddSource(func() (map[string]interface{}, error) {
+		return nil, errors.New("failed")
+	})
+
+	suite := godog.TestSuite{
+		TestSuiteInitializer: vs.RegisterSuite,
+		ScenarioInitializer:  vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Output: io.Discard,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Suite.feature",
+					Contents: []byte(`Feature: Suite
+  Scenario: Preloaded variables
+    Given variable $baseURL is undefined
+`),
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run())
+
+	err := vs.SuiteError()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "loading variables from source 1: open ")
+	assert.Contains(t, err.Error(), "; loading variables from source 2: failed")
+}
+
+func TestSteps_SuiteError_single(t *testing.T) {
+	vs := &vars.Steps{}
+	vs.AddSource(vars.FileSource(filepath.Join(t.TempDir(), "missing.json")))
+
+	suite := godog.TestSuite{
+		TestSuiteInitializer: vs.RegisterSuite,
+		ScenarioInitializer:  vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Output: io.Discard,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Suite.feature",
+					Contents: []byte(`Feature: Suite
+  Scenario: Preloaded variables
+    Given variable $baseURL is undefined
+`),
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run())
+
+	// Single error is wrapped, so it can be inspected with errors.Is and errors.As.
+	assert.ErrorIs(t, vs.SuiteError(), os.ErrNotExist)
+}
@@ -0,0 +1,347 @@
+package vars
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cucumber/godog"
+)
+
+// tableRow is a row of variables table with indexes of rows it depends on.
+type tableRow struct {
+	name  string
+	value string
+	deps  []int
+}
+
+// tableGraph is a dependency graph of variables table rows.
+type tableGraph struct {
+	rows       []tableRow
+	dependents [][]int
+}
+
+// walkVars evaluates rows of variables table in order of their dependencies and calls cb for each variable.
+//
+// Row that uses a variable defined in another row of the table is evaluated after that row,
+// rows with overridden values are not evaluated.
+func (s *Steps) walkVars(ctx context.Context, table *godog.Table, override map[string]interface{}, cb func(name string, val interface{})) error {
+	ctx, v := s.Vars(ctx)
+
+	g, err := newTableGraph(table, override, v.GetAll())
+	if err != nil {
+		return err
+	}
+
+	if s.TableConcurrency > 1 {
+		return s.walkVarsParallel(ctx, g, override, cb)
+	}
+
+	order, err := g.order()
+	if err != nil {
+		return err
+	}
+
+	for _, i := range order {
+		r := g.rows[i]
+
+		if v, found := override[r.name]; found {
+			cb(r.name, v)
+
+			continue
+		}
+
+		_, val, err := s.value(context.WithValue(ctx, varNameCtxKey{}, r.name), r.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.name, err)
+		}
+
+		cb(r.name, val)
+	}
+
+	return nil
+}
+
+// walkVarsParallel evaluates independent rows concurrently, up to TableConcurrency rows at once.
+//
+// Callbacks are called sequentially as soon as a row is evaluated.
+func (s *Steps) walkVarsParallel(ctx context.Context, g tableGraph, override map[string]interface{}, cb func(name string, val interface{})) error {
+	if _, err := g.order(); err != nil {
+		return err
+	}
+
+	type result struct {
+		i   int
+		val interface{}
+		err error
+	}
+
+	var (
+		results  = make(chan result)
+		pending  = make([]int, len(g.rows))
+		ready    []int
+		running  int
+		firstErr error
+	)
+
+	resolve := func(i int, val interface{}) {
+		cb(g.rows[i].name, val)
+
+		for _, j := range g.dependents[i] {
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	for i, r := range g.rows {
+		pending[i] = len(r.deps)
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	for {
+		for firstErr == nil && len(ready) > 0 && running < s.TableConcurrency {
+			sort.Ints(ready)
+
+			i := ready[0]
+			ready = ready[1:]
+
+			if v, found := override[g.rows[i].name]; found {
+				resolve(i, v)
+
+				continue
+			}
+
+			running++
+
+			go func(i int) {
+				_, val, err := s.value(context.WithValue(ctx, varNameCtxKey{}, g.rows[i].name), g.rows[i].value)
+				results <- result{i: i, val: val, err: err}
+			}(i)
+		}
+
+		if running == 0 {
+			return firstErr
+		}
+
+		res := <-results
+		running--
+
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", g.rows[res.i].name, res.err)
+			}
+
+			continue
+		}
+
+		// Rows that are already evaluated are kept even if another row failed, e.g. to clean them up.
+		resolve(res.i, res.val)
+	}
+}
+
+// newTableGraph finds dependencies between rows of variables table.
+//
+// Row depends on rows that define variables used in its value, and on previous rows with same name,
+// a row that uses its own name refers to previous value of the variable.
+//
+// Variable defined in rows above or in scope is used with its value at that point of the table,
+// a later row is only used as dependency if variable is not defined yet, then it takes the last value.
+// Other variables in scope are also used to tell longer variable names, same as replacement does.
+func newTableGraph(table *godog.Table, override map[string]interface{}, scope map[string]interface{}) (tableGraph, error) {
+	g := tableGraph{
+		rows:       make([]tableRow, 0, len(table.Rows)),
+		dependents: make([][]int, len(table.Rows)),
+	}
+
+	byName := make(map[string][]int)
+	names := make([]string, 0, len(table.Rows)+len(scope))
+
+	for i, row := range table.Rows {
+		if len(row.Cells) != 2 {
+			return g, fmt.Errorf("two columns expected in the table, %d received", len(row.Cells))
+		}
+
+		name := row.Cells[0].Value
+
+		if _, found := byName[name]; !found {
+			names = append(names, name)
+		}
+
+		byName[name] = append(byName[name], i)
+		g.rows = append(g.rows, tableRow{name: name, value: row.Cells[1].Value})
+	}
+
+	for name := range scope {
+		if _, found := byName[name]; !found {
+			names = append(names, name)
+		}
+	}
+
+	sortVarNames(names)
+
+	for i := range g.rows {
+		r := &g.rows[i]
+
+		if _, found := override[r.name]; found {
+			continue
+		}
+
+		for _, name := range references(r.value, names) {
+			if name == r.name {
+				continue
+			}
+
+			if j := previousRow(byName[name], i); j >= 0 {
+				r.deps = append(r.deps, j)
+
+				continue
+			}
+
+			// Existing value is used as is, table is still read from top to bottom.
+			if _, found := scope[name]; found {
+				continue
+			}
+
+			if same := byName[name]; len(same) > 0 {
+				r.deps = append(r.deps, same[len(same)-1])
+			}
+		}
+
+		// Variable defined in multiple rows takes value of the last one.
+		if same := byName[r.name]; same[0] != i {
+			for k, j := range same {
+				if j == i {
+					r.deps = append(r.deps, same[k-1])
+
+					break
+				}
+			}
+		}
+
+		for _, j := range r.deps {
+			g.dependents[j] = append(g.dependents[j], i)
+		}
+	}
+
+	return g, nil
+}
+
+// previousRow returns the last of rows that is above row i, or -1.
+func previousRow(rows []int, i int) int {
+	prev := -1
+
+	for _, j := range rows {
+		if j >= i {
+			break
+		}
+
+		prev = j
+	}
+
+	return prev
+}
+
+// order returns indexes of rows sorted by dependencies, independent rows keep table order.
+func (g tableGraph) order() ([]int, error) {
+	pending := make([]int, len(g.rows))
+	order := make([]int, 0, len(g.rows))
+
+	var ready []int
+
+	for i, r := range g.rows {
+		pending[i] = len(r.deps)
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	for len(ready) > 0 {
+		sort.Ints(ready)
+
+		i := ready[0]
+		ready = ready[1:]
+		order = append(order, i)
+
+		for _, j := range g.dependents[i] {
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	if len(order) < len(g.rows) {
+		return nil, g.cycleError(pending)
+	}
+
+	return order, nil
+}
+
+// cycleError names a dependency cycle among rows that could not be ordered.
+func (g tableGraph) cycleError(pending []int) error {
+	start := 0
+	for pending[start] == 0 {
+		start++
+	}
+
+	// Every unordered row depends on another unordered row, so following such dependencies leads to a cycle.
+	visited := make(map[int]int)
+	path := []int{}
+
+	for i := start; ; {
+		if pos, found := visited[i]; found {
+			path = append(path[pos:], i)
+
+			break
+		}
+
+		visited[i] = len(path)
+		path = append(path, i)
+
+		for _, j := range g.rows[i].deps {
+			if pending[j] > 0 {
+				i = j
+
+				break
+			}
+		}
+	}
+
+	names := make([]string, 0, len(path))
+	for _, i := range path {
+		names = append(names, g.rows[i].name)
+	}
+
+	return fmt.Errorf("dependency cycle in variables: %s", strings.Join(names, " -> "))
+}
+
+// references returns names that are used in value, longer names are matched first.
+func references(value string, names []string) []string {
+	var res []string
+
+	for _, name := range names {
+		if name != "" && strings.Contains(value, name) {
+			res = append(res, name)
+			value = strings.ReplaceAll(value, name, "\x00")
+		}
+	}
+
+	return res
+}
+
+// sortVarNames sorts names by length in descending order, so that longer names are replaced first.
+func sortVarNames(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		// Compare lengths first (longer comes first)
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		// If lengths are equal, sort alphabetically
+		return names[i] < names[j]
+	})
+}
@@ -0,0 +1,99 @@
+package vars_test
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps_walkVars_cycle(t *testing.T) {
+	vs := &vars.Steps{}
+	vs.AddStdFactories()
+
+	out := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Output: out,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Cycle.feature",
+					Contents: []byte(`Feature: Cycle
+  Scenario: Cyclic dependency
+    Given variables are set to values
+      | $a | 1          |
+      | $b | $c + 1     |
+      | $c | upper("$d") |
+      | $d | $b + $a    |
+`),
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, 1, suite.Run())
+	assert.Contains(t, out.String(), "dependency cycle in variables: $b -> $c -> $d -> $b")
+}
+
+func TestSteps_TableConcurrency(t *testing.T) {
+	var running, maxRunning int64
+
+	vs := &vars.Steps{TableConcurrency: 4}
+	vs.AddFactory("newUser", func(ctx context.Context, args ...interface{}) (context.Context, interface{}, error) {
+		n := atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+
+		for {
+			m := atomic.LoadInt64(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		return ctx, args[0], nil
+	})
+
+	suite := godog.TestSuite{
+		ScenarioInitializer: vs.Register,
+		Options: &godog.Options{
+			Format: "progress",
+			Strict: true,
+			FeatureContents: []godog.Feature{
+				{
+					Name: "Parallel.feature",
+					Contents: []byte(`Feature: Parallel
+  Scenario: Independent rows
+    Given variables are set to values
+      | $u1    | newUser(1)        |
+      | $u2    | newUser(2)        |
+      | $u3    | newUser(3)        |
+      | $u4    | newUser(4)        |
+      | $u5    | newUser(5)        |
+      | $u6    | newUser(6)        |
+      | $total | newUser($u1 + $u6) |
+
+    Then variables are equal to values
+      | $u1    | 1 |
+      | $u6    | 6 |
+      | $total | 7 |
+`),
+				},
+			},
+		},
+	}
+
+	assert.Zero(t, suite.Run())
+	// Rows are evaluated in parallel, but not more than TableConcurrency at once.
+	assert.Greater(t, atomic.LoadInt64(&maxRunning), int64(1))
+	assert.LessOrEqual(t, atomic.LoadInt64(&maxRunning), int64(4))
+}
@@ -0,0 +1,213 @@
+package vars
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// replaceTemplates evaluates ${expr} templates with factories, generators and variables.
+//
+// Template that is a whole JSON string, e.g. "${newUserID("x")}", is replaced with JSON value of the result,
+// template that is a part of JSON string is replaced with escaped string value of the result.
+// Inside JSON strings quotes of expression are escaped, e.g. "id-${upper(\"x\")}",
+// inside single-quoted JSON5 strings they are not, e.g. 'id-${upper("x")}'.
+// Literal ${ can be escaped as $${, text that is not a valid expression, e.g. ${HOME}, is kept as is.
+func (s *Steps) replaceTemplates(ctx context.Context, body []byte) (context.Context, []byte, error) {
+	var (
+		out         bytes.Buffer
+		quote       byte // Quote of current string, or 0 outside of strings.
+		stringStart int
+	)
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+
+		switch {
+		case c == '\\' && quote != 0 && i+1 < len(body):
+			out.Write(body[i : i+2])
+			i++
+
+			continue
+		case (c == '"' || c == '\'') && (quote == 0 || quote == c):
+			if quote == 0 {
+				quote = c
+				stringStart = out.Len()
+			} else {
+				quote = 0
+			}
+		case c == '$' && bytes.HasPrefix(body[i+1:], []byte("${")):
+			out.WriteString("${")
+
+			i += 2
+
+			continue
+		case c == '$' && bytes.HasPrefix(body[i+1:], []byte("{")):
+			end, ok := templateEnd(body, i+2, quote)
+			if !ok {
+				break
+			}
+
+			src := string(body[i+2 : end])
+
+			expr, err := unquoteTemplate(src, quote)
+			if err != nil {
+				return ctx, nil, fmt.Errorf("decoding template ${%s}: %w", src, err)
+			}
+
+			n, err := s.parseExpr(expr)
+			if err != nil {
+				// Not an expression, e.g. shell variable or JS template literal.
+				out.Write(body[i : end+1])
+
+				i = end
+
+				continue
+			}
+
+			ctx, val, err := s.evalExpr(s.PrepareContext(ctx), n)
+			if err != nil {
+				return ctx, nil, fmt.Errorf("evaluating template ${%s}: %w", expr, err)
+			}
+
+			wholeString := quote != 0 && out.Len() == stringStart+1 && end+1 < len(body) && body[end+1] == quote
+
+			if err := writeTemplateValue(&out, val, quote, wholeString, stringStart); err != nil {
+				return ctx, nil, fmt.Errorf("template ${%s}: %w", expr, err)
+			}
+
+			i = end
+
+			// Whole JSON string is replaced with a value, closing quote is consumed.
+			if wholeString {
+				i++
+				quote = 0
+			}
+
+			continue
+		}
+
+		out.WriteByte(c)
+	}
+
+	return ctx, out.Bytes(), nil
+}
+
+// unquoteTemplate decodes expression of a template in a string with quote.
+func unquoteTemplate(src string, quote byte) (string, error) {
+	switch quote {
+	case '"':
+		var expr string
+
+		err := json.Unmarshal([]byte(`"`+src+`"`), &expr)
+
+		return expr, err
+	case '\'':
+		// Only escaped single quotes are decoded, expression strings are JSON strings.
+		return strings.ReplaceAll(src, `\'`, `'`), nil
+	default:
+		return src, nil
+	}
+}
+
+func writeTemplateValue(out *bytes.Buffer, val interface{}, quote byte, wholeString bool, stringStart int) error {
+	if quote != 0 && !wholeString {
+		str, err := asString(val)
+		if err != nil {
+			return err
+		}
+
+		j, err := json.Marshal(str)
+		if err != nil {
+			return err
+		}
+
+		j = j[1 : len(j)-1]
+
+		if quote == '\'' {
+			j = bytes.ReplaceAll(j, []byte(`'`), []byte(`\'`))
+		}
+
+		out.Write(j)
+
+		return nil
+	}
+
+	j, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	if wholeString {
+		out.Truncate(stringStart)
+		out.Write(j)
+
+		return nil
+	}
+
+	// Outside of JSON strings, string values are inserted without quotes, same as variables.
+	if len(j) > 1 && j[0] == '"' && j[len(j)-1] == '"' {
+		j = j[1 : len(j)-1]
+	}
+
+	out.Write(j)
+
+	return nil
+}
+
+// templateEnd finds closing brace of a template that starts at pos in a string with quote.
+//
+// It returns false if template is not terminated before the end of the string.
+func templateEnd(body []byte, pos int, quote byte) (int, bool) {
+	depth := 0
+	inExpr := false
+
+	for j := pos; j < len(body); j++ {
+		c := body[j]
+
+		switch {
+		case quote == '"' && c == '\\':
+			// Quotes of expression strings are escaped inside JSON string.
+			if j+1 < len(body) && body[j+1] == '"' {
+				inExpr = !inExpr
+			}
+
+			j++
+
+			continue
+		case quote == '\'' && c == '\\':
+			j++
+
+			continue
+		case quote != 0 && c == quote:
+			return 0, false
+		case quote != '"' && inExpr && c == '\\':
+			j++
+
+			continue
+		case quote != '"' && c == '"':
+			inExpr = !inExpr
+
+			continue
+		}
+
+		if inExpr {
+			continue
+		}
+
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return j, true
+			}
+
+			depth--
+		}
+	}
+
+	return 0, false
+}
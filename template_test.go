@@ -0,0 +1,90 @@
+package vars_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/godogx/vars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSteps_Replace_templates(t *testing.T) {
+	vs := &vars.Steps{}
+	vs.AddStdFactories()
+	vs.AddGenerator("nonce", func() (interface{}, error) {
+		return "n-1", nil
+	})
+	vs.AddFunc("newUserID", func(name string) int { return len(name) })
+	vs.AddFunc("fail", func() (int, error) { return 0, errors.New("failed") })
+
+	ctx := vars.ToContext(context.Background(), "$name", "John")
+
+	for _, tc := range []struct {
+		body     string
+		expected string
+		err      string
+	}{
+		{
+			body:     `{"id": "${newUserID(\"x\")}", "nonce": "${gen:nonce}", "name": "$name"}`,
+			expected: `{"id":1,"nonce":"n-1","name":"John"}`,
+		},
+		{
+			body:     `{"greeting": "Hello, ${upper(\"$name\")}! ${1 + 2}", "ids": [${newUserID("ab")}, ${$name | len}]}`,
+			expected: `{"greeting":"Hello, JOHN! 3","ids":[2,4]}`,
+		},
+		{
+			body:     `{"obj": "${jsonDecode(\"{\\\"a\\\":[1]}\")}", "nested": "${concat(\"{\", \"}\")}"}`,
+			expected: `{"obj":{"a":[1]},"nested":"{}"}`,
+		},
+		{
+			body:     `Dear ${$name}, your code is ${upper("abc")}, cost is $${price}.`,
+			expected: `Dear John, your code is ABC, cost is ${price}.`,
+		},
+		{
+			body: `{"id": "${unknown()}"}`,
+			err:  `evaluating template ${unknown()}: unknown factory unknown at col 1`,
+		},
+		{
+			body: `{"id": "${fail()}"}`,
+			err:  `evaluating template ${fail()}: calling fail at col 1: failed`,
+		},
+		{
+			// Unterminated template is kept as is.
+			body:     `{"id": "${upper(\"a\")"}`,
+			expected: `{"id":"${upper(\"a\")"}`,
+		},
+		{
+			// Text that is not an expression is kept as is.
+			body:     `{"cmd": "echo ${HOME}", "js": "const s = ` + "`${a + b}`" + `", "name": "$name"}`,
+			expected: `{"cmd":"echo ${HOME}","js":"const s = ` + "`${a + b}`" + `","name":"John"}`,
+		},
+		{
+			body: `{'id': '${newUserID("x")}', 'greeting': 'It\'s ${upper("$name")}', 'quote': '${concat("\'", "a")}', ` +
+				`'partial': '${concat("\'", "b")}!'}`,
+			expected: `{'id': 1, 'greeting': 'It\'s JOHN', 'quote': "'a", 'partial': '\'b!'}`,
+		},
+		{
+			body:     `{"quote": "it's ${upper(\"a\")}", 'cmd': 'echo ${HOME}'}`,
+			expected: `{"quote": "it's A", 'cmd': 'echo ${HOME}'}`,
+		},
+	} {
+		t.Run(tc.body, func(t *testing.T) {
+			_, res, err := vs.ReplaceString(ctx, tc.body)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+
+	// Templates are not evaluated without Steps.
+	_, res, err := vars.Replace(ctx, []byte(`{"id":"${newUserID(\"x\")}"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"${newUserID(\"x\")}"}`, string(res))
+}